@@ -0,0 +1,76 @@
+package shifter
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/mayur-tolexo/flaw"
+)
+
+//ErrMigrationLocked is returned if advisory lock is not acquired within the lock timeout
+var ErrMigrationLocked = errors.New("migration advisory lock is held by other process")
+
+//AdvisoryLock will enable postgresql advisory lock derived from key in
+//CreateAllTable, AlterAllTable, DropAllTable, AlterAllSchemas and Plan
+//so that only one process migrates the database at a time.
+//Process waits for the lock till timeout (0 means no timeout)
+//and the changes are planned after the lock is acquired.
+//Empty key disables the lock
+func (s *Shifter) AdvisoryLock(key string, timeout time.Duration) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockKey = key
+	s.lockWait = timeout
+	return s
+}
+
+//advisoryLock will acquire the advisory lock if enabled
+//lock is held by a transaction which is rolled back in unlock()
+func (s *Shifter) advisoryLock(conn Executor) (err error) {
+	if s.lockKey != "" {
+		var (
+			tx     Tx
+			locked bool
+		)
+		lockID := getAdvisoryLockID(s.lockKey)
+		if tx, err = conn.Begin(); err == nil {
+			query := `SELECT pg_try_advisory_xact_lock(?);`
+			if err = tx.Query(&locked, query, lockID); err == nil && locked == false {
+				fmt.Println("Waiting for migration lock:", s.lockKey)
+				query = fmt.Sprintf("SET LOCAL lock_timeout = %v;\nSELECT pg_advisory_xact_lock(?);",
+					getMillisecond(s.lockWait))
+				_, err = tx.Exec(query, lockID)
+			}
+			if err == nil {
+				s.run.lockTx = tx
+			} else {
+				tx.Rollback()
+				if isLockTimeout(err) {
+					err = ErrMigrationLocked
+				} else {
+					err = getWrapError(s.lockKey, "advisory lock", query, err)
+				}
+			}
+		} else {
+			err = flaw.TxError(err)
+		}
+	}
+	return
+}
+
+//releaseAdvisoryLock will release the advisory lock if acquired
+func (s *Shifter) releaseAdvisoryLock() {
+	if s.run.lockTx != nil {
+		s.run.lockTx.Rollback()
+		s.run.lockTx = nil
+	}
+}
+
+//getAdvisoryLockID will return advisory lock id of the key
+func getAdvisoryLockID(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
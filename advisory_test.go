@@ -0,0 +1,25 @@
+package shifter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvisoryLock(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(getAdvisoryLockID("my-service"), getAdvisoryLockID("my-service"))
+	assert.NotEqual(getAdvisoryLockID("my-service"), getAdvisoryLockID("other-service"))
+
+	//lock is not taken if key is not set
+	s := NewShifter()
+	s.lock()
+	assert.NoError(s.advisoryLock(nil))
+	assert.Nil(s.run.lockTx)
+	s.unlock()
+
+	s.AdvisoryLock("my-service", time.Minute)
+	assert.Equal("my-service", s.lockKey)
+	assert.Equal(time.Minute, s.lockWait)
+}
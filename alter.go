@@ -13,14 +13,16 @@ import (
 )
 
 //Alter Table
-func (s *Shifter) alterTable(tx *pg.Tx, tableName string,
+func (s *Shifter) alterTable(tx Tx, tableName string,
 	skipPrompt bool) (err error) {
 
 	var (
-		tSchema           map[string]model.ColSchema
-		tUK               []model.UKSchema
-		idx               []model.Index
-		colAlter, ukAlter bool
+		tSchema                     map[string]model.ColSchema
+		tUK                         []model.UKSchema
+		tKey                        []model.KeySchema
+		idx                         []model.Index
+		colAlter, ukAlter, idxAlter bool
+		conAlter, invalidAlter      bool
 	)
 	_, isValid := s.table[tableName]
 	defer s.logMode(false)
@@ -28,22 +30,31 @@ func (s *Shifter) alterTable(tx *pg.Tx, tableName string,
 	if isValid == true {
 
 		if tSchema, err = s.getTableSchema(tx, tableName); err == nil {
-			sSchema := s.GetStructSchema(tableName)
+			sSchema := s.getStructSchema(tableName)
 
-			if s.hisExists, err = util.IsAfterUpdateTriggerExists(tx, tableName); err == nil {
+			if s.hisExists, err = s.afterUpdateTriggerExists(tx, tableName); err == nil {
 
 				//checking enum to update
 				if err = s.upsertAllEnum(tx, tableName); err == nil {
-					//checking column to update
-					if colAlter, err = s.compareSchema(tx, tSchema, sSchema, skipPrompt); err == nil {
-						//checking composite unique key to update
-						tUK, ukAlter, err = s.modifyCompositeUniqueKey(tx, tableName)
-						//TODO: check index to update
-					}
-					if err == nil && (colAlter || ukAlter) {
-						if idx, err = getDBIndex(tx, tableName); err == nil {
-							err = s.createAlterStructLog(tSchema, tUK, idx, true)
+					//checking composite primary/foreign key and check constraint to update
+					tKey, conAlter, err = s.modifyTableConstraint(tx, tableName, skipPrompt, func() (err error) {
+						//checking column to update
+						if colAlter, err = s.compareSchema(tx, tSchema, sSchema, skipPrompt); err == nil {
+							//invalid index left by failed concurrent build is dropped
+							//before unique key and index are built again
+							if invalidAlter, err = s.dropInvalidIndex(tx, tableName, skipPrompt); err == nil {
+								//checking composite unique key to update
+								if tUK, ukAlter, err = s.modifyCompositeUniqueKey(tx, tableName); err == nil {
+									//checking index to update
+									idx, idxAlter, err = s.modifyIndex(tx, tableName, skipPrompt)
+								}
+							}
 						}
+						return
+					})
+					if err == nil && (colAlter || invalidAlter || ukAlter || idxAlter || conAlter) &&
+						s.run.dryRun == false {
+						err = s.createAlterStructLog(tSchema, tUK, tKey, idx, true)
 					}
 				}
 			}
@@ -54,13 +65,38 @@ func (s *Shifter) alterTable(tx *pg.Tx, tableName string,
 	return
 }
 
+//modifyTableConstraint will modify composite primary/foreign keys and check constraints
+//if changed in struct. Modified constraints are dropped before the column alter
+//and added after it so that the constrained columns can be altered
+func (s *Shifter) modifyTableConstraint(tx Tx, tableName string, skipPrompt bool,
+	alterCol func() error) (tKey []model.KeySchema, isAlter bool, err error) {
+
+	var (
+		sKey                 map[string]model.KeySchema
+		sCheck               map[string]string
+		keyDrop, checkDrop   bool
+		keyAdded, checkAdded bool
+	)
+	if tKey, sKey, keyDrop, err = s.dropCompositeKey(tx, tableName, skipPrompt); err == nil {
+		if sCheck, checkDrop, err = s.dropCheck(tx, tableName, skipPrompt); err == nil {
+			if err = alterCol(); err == nil {
+				if keyAdded, err = s.addCompositeKey(tx, tableName, sKey, skipPrompt); err == nil {
+					checkAdded, err = s.addCheck(tx, tableName, sCheck, skipPrompt)
+				}
+			}
+		}
+	}
+	isAlter = keyDrop || checkDrop || keyAdded || checkAdded
+	return
+}
+
 //modifyCompositeUniqueKey will modify composite unique key if changed in struct
-func (s *Shifter) modifyCompositeUniqueKey(tx *pg.Tx,
+func (s *Shifter) modifyCompositeUniqueKey(tx Tx,
 	tableName string) (tUK []model.UKSchema, isAlter bool, err error) {
 
 	defer func() { s.logMode(false) }()
 	sUK := s.getUKFromMethod(tableName)
-	if tUK, err = getDBCompositeUniqueKey(tx, tableName); err == nil &&
+	if tUK, err = s.catalog(tx).CompositeUniqueKey(tableName); err == nil &&
 		(len(tUK) > 0 || len(sUK) > 0) {
 		s.logMode(s.verbose)
 		isAlter, err = s.checkUniqueKeyToAlter(tx, tableName, tUK, sUK)
@@ -69,15 +105,15 @@ func (s *Shifter) modifyCompositeUniqueKey(tx *pg.Tx,
 }
 
 //getTableSchema will return table schema
-func (s *Shifter) getTableSchema(tx *pg.Tx, tableName string) (
+func (s *Shifter) getTableSchema(tx Tx, tableName string) (
 	tSchema map[string]model.ColSchema, err error) {
 	var (
 		columnSchema []model.ColSchema
 		constraint   []model.ColSchema
 	)
 	s.logMode(false)
-	if columnSchema, err = getColumnSchema(tx, tableName); err == nil {
-		if constraint, err = getConstraint(tx, tableName); err == nil {
+	if columnSchema, err = s.catalog(tx).ColumnSchema(tableName); err == nil {
+		if constraint, err = s.catalog(tx).Constraint(tableName); err == nil {
 			tSchema = mergeColumnConstraint(tableName, columnSchema, constraint)
 		}
 	}
@@ -85,10 +121,11 @@ func (s *Shifter) getTableSchema(tx *pg.Tx, tableName string) (
 }
 
 //compareSchema will compare then table and struct column scheam and change accordingly
-func (s *Shifter) compareSchema(tx *pg.Tx, tSchema, sSchema map[string]model.ColSchema,
+func (s *Shifter) compareSchema(tx Tx, tSchema, sSchema map[string]model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
 	var (
+		renamed bool
 		added   bool
 		removed bool
 		modify  bool
@@ -97,26 +134,29 @@ func (s *Shifter) compareSchema(tx *pg.Tx, tSchema, sSchema map[string]model.Col
 	defer s.logMode(false)
 	s.logMode(s.verbose)
 
-	//adding column exists in struct but missing in db table
-	if added, err = s.addRemoveCol(tx, sSchema, tSchema, add, skipPrompt); err == nil {
-		//removing column exists in db table but missing in struct
-		if removed, err = s.addRemoveCol(tx, tSchema, sSchema, drop, skipPrompt); err == nil {
-			//modify column
-			modify, err = s.modifyCol(tx, tSchema, sSchema, skipPrompt)
+	//renaming column as defined in struct Renames() method
+	if renamed, err = s.renameCol(tx, tSchema, sSchema, skipPrompt); err == nil {
+		//adding column exists in struct but missing in db table
+		if added, err = s.addRemoveCol(tx, sSchema, tSchema, add, skipPrompt); err == nil {
+			//removing column exists in db table but missing in struct
+			if removed, err = s.addRemoveCol(tx, tSchema, sSchema, drop, skipPrompt); err == nil {
+				//modify column
+				modify, err = s.modifyCol(tx, tSchema, sSchema, skipPrompt)
+			}
 		}
 	}
 
-	//recreating trigger only if added or removed column
-	if err == nil && (added || removed) {
+	//recreating trigger only if renamed, added or removed column
+	if err == nil && (renamed || added || removed) {
 		tName := getTableName(sSchema)
 		err = s.createTrigger(tx, tName)
 	}
-	isAlter = (added || removed || modify)
+	isAlter = (renamed || added || removed || modify)
 	return
 }
 
 //addRemoveCol will add/drop missing column which exists in a but not in b
-func (s *Shifter) addRemoveCol(tx *pg.Tx, a, b map[string]model.ColSchema,
+func (s *Shifter) addRemoveCol(tx Tx, a, b map[string]model.ColSchema,
 	op string, skipPrompt bool) (isAlter bool, err error) {
 
 	for col, schema := range a {
@@ -135,12 +175,17 @@ func (s *Shifter) addRemoveCol(tx *pg.Tx, a, b map[string]model.ColSchema,
 }
 
 //alterCol will add/drop column in table
-func (s *Shifter) alterCol(tx *pg.Tx, schema model.ColSchema,
+func (s *Shifter) alterCol(tx Tx, schema model.ColSchema,
 	op string, skipPrompt bool) (isAlter bool, err error) {
 
 	switch op {
 	case add:
-		isAlter, err = s.addCol(tx, schema, skipPrompt)
+		var online bool
+		if online, err = s.isOnlineAddCol(tx, schema); err == nil && online {
+			isAlter, err = s.addColOnline(tx, schema, skipPrompt)
+		} else if err == nil {
+			isAlter, err = s.addCol(tx, schema, skipPrompt)
+		}
 	case drop:
 		isAlter, err = s.dropCol(tx, schema, skipPrompt)
 	}
@@ -148,11 +193,13 @@ func (s *Shifter) alterCol(tx *pg.Tx, schema model.ColSchema,
 }
 
 //alterCol will add column in table
-func (s *Shifter) addCol(tx *pg.Tx, schema model.ColSchema,
+func (s *Shifter) addCol(tx Tx, schema model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
+	schema = s.getEnumColSchema(schema)
 	dType := getAddColTypeSQL(schema)
 	sql := getAddColSQL(schema.TableName, schema.ColumnName, dType)
+	downSQL := getDropColSQL(schema.TableName, schema.ColumnName)
 	cSQL := getAddConstraintSQL(schema)
 
 	if cSQL != "" {
@@ -166,12 +213,13 @@ func (s *Shifter) addCol(tx *pg.Tx, schema model.ColSchema,
 		hName := util.GetHistoryTableName(schema.TableName)
 		dType = getStructDataType(schema)
 		sql += getAddColSQL(hName, schema.ColumnName, dType)
+		downSQL += getDropColSQL(hName, schema.ColumnName)
 	}
 	//history alter sql end
 
-	if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-		err = getWrapError(schema.TableName, "add column", sql, err)
-	}
+	c := newChange(AddColumnChange, schema.TableName, schema.ColumnName, sql)
+	c.DownSQL = downSQL
+	isAlter, err = s.execByChoice(tx, c, skipPrompt)
 	return
 }
 
@@ -182,20 +230,26 @@ func getAddColSQL(tName, cName, dType string) (sql string) {
 }
 
 //dropCol will drop column from table
-func (s *Shifter) dropCol(tx *pg.Tx, schema model.ColSchema,
+func (s *Shifter) dropCol(tx Tx, schema model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
 	sql := getDropColSQL(schema.TableName, schema.ColumnName)
+	schema = s.getEnumColSchema(schema)
+	downSQL := getAddColSQL(schema.TableName, schema.ColumnName,
+		getAddColTypeSQL(schema)) + ";\n"
 	//checking history table exists
 	if s.hisExists && schema.ColumnName != "updated_at" {
 		hName := util.GetHistoryTableName(schema.TableName)
 		sql += getDropColSQL(hName, schema.ColumnName)
+		downSQL += getAddColSQL(hName, schema.ColumnName,
+			getStructDataType(schema)) + ";\n"
 	}
 	//history alter sql end
 
-	if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-		err = getWrapError(schema.TableName, "drop column", sql, err)
-	}
+	c := newChange(DropColumnChange, schema.TableName, schema.ColumnName, sql)
+	//dropped column data can't be restored only column is added back
+	c.DownSQL = downSQL
+	isAlter, err = s.execByChoice(tx, c, skipPrompt)
 	return
 }
 
@@ -216,7 +270,7 @@ func getConstraintName(schema model.ColSchema) (keyName string) {
 	case foreignKey:
 		tag = foreignKeySuffix
 	}
-	keyName = fmt.Sprintf("%v_%v_%v", schema.TableName, schema.ColumnName, tag)
+	keyName = fmt.Sprintf("%v_%v_%v", util.GetBareName(schema.TableName), schema.ColumnName, tag)
 	return
 }
 
@@ -346,7 +400,7 @@ func getUniqueDTypeSQL(schema model.ColSchema) (str string) {
 }
 
 //modifyCol will modify column of table by comparing with struct
-func (s *Shifter) modifyCol(tx *pg.Tx, tSchema, sSchema map[string]model.ColSchema,
+func (s *Shifter) modifyCol(tx Tx, tSchema, sSchema map[string]model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
 	for col, tcSchema := range tSchema {
@@ -385,17 +439,22 @@ func (s *Shifter) modifyCol(tx *pg.Tx, tSchema, sSchema map[string]model.ColSche
 }
 
 //modifyNotNullConstraint will modify not null by comparing table and structure
-func (s *Shifter) modifyNotNullConstraint(tx *pg.Tx, tSchema, sSchema model.ColSchema,
+func (s *Shifter) modifyNotNullConstraint(tx Tx, tSchema, sSchema model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
 	if tSchema.IsNullable != sSchema.IsNullable {
-		option := set
-		if sSchema.IsNullable == yes {
-			option = drop
-		}
-		sql := getNotNullColSQL(sSchema.TableName, sSchema.ColumnName, option)
-		if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-			err = getWrapError(sSchema.TableName, "modify not null", sql, err)
+		if sSchema.IsNullable == no && s.online {
+			//setting not null without scanning the table under lock
+			isAlter, err = s.setNotNullOnline(tx, sSchema, skipPrompt)
+		} else {
+			option, downOption := set, drop
+			if sSchema.IsNullable == yes {
+				option, downOption = drop, set
+			}
+			sql := getNotNullColSQL(sSchema.TableName, sSchema.ColumnName, option)
+			c := newChange(ModifyNotNullChange, sSchema.TableName, sSchema.ColumnName, sql)
+			c.DownSQL = getNotNullColSQL(sSchema.TableName, sSchema.ColumnName, downOption)
+			isAlter, err = s.execByChoice(tx, c, skipPrompt)
 		}
 	}
 	return
@@ -409,7 +468,7 @@ func getNotNullColSQL(tName, cName, option string) (sql string) {
 }
 
 //modifyDataType will modify column data type by comparing with structure
-func (s *Shifter) modifyDataType(tx *pg.Tx, tSchema, sSchema model.ColSchema,
+func (s *Shifter) modifyDataType(tx Tx, tSchema, sSchema model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
 	tDataType := getStructDataType(tSchema)
@@ -418,6 +477,8 @@ func (s *Shifter) modifyDataType(tx *pg.Tx, tSchema, sSchema model.ColSchema,
 	// fmt.Println("DType", tSchema.ColumnName, "T", tDataType, "S", sDataType)
 
 	if tDataType != sDataType {
+		tDataType = getStructDataType(s.getEnumColSchema(tSchema))
+		sDataType = getStructDataType(s.getEnumColSchema(sSchema))
 		//dropping default sql
 		sql := getDropDefaultSQL(sSchema.TableName, sSchema.ColumnName)
 		//modifying column type
@@ -425,16 +486,25 @@ func (s *Shifter) modifyDataType(tx *pg.Tx, tSchema, sSchema model.ColSchema,
 		//adding back default sql
 		sql += getSetDefaultSQL(sSchema.TableName, sSchema.ColumnName, sSchema.ColumnDefault)
 
+		//reverse sql to modify back to table data type and default
+		downSQL := getDropDefaultSQL(tSchema.TableName, tSchema.ColumnName)
+		downSQL += getModifyColSQL(tSchema.TableName, tSchema.ColumnName, tDataType, tDataType)
+		downSQL += getSetDefaultSQL(tSchema.TableName, tSchema.ColumnName, tSchema.ColumnDefault)
+
 		//checking history table exists
 		if s.hisExists {
 			hName := util.GetHistoryTableName(sSchema.TableName)
 			sql += getModifyColSQL(hName, sSchema.ColumnName, sDataType, sDataType)
+			downSQL += getModifyColSQL(hName, tSchema.ColumnName, tDataType, tDataType)
 		}
 		//history alter sql end
 
-		if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-			err = getWrapError(sSchema.TableName, "modify datatype", sql, err)
+		c := newChange(ModifyDataTypeChange, sSchema.TableName, sSchema.ColumnName, sql)
+		c.DownSQL = downSQL
+		if isNarrowing(tSchema, sSchema) {
+			c.Safety = LossyLevel
 		}
+		isAlter, err = s.execByChoice(tx, c, skipPrompt)
 	}
 
 	return
@@ -449,7 +519,7 @@ func getModifyColSQL(tName, cName, dType, udtType string) (sql string) {
 }
 
 //modifyDefault will modify default value by comparing table and structure
-func (s *Shifter) modifyDefault(tx *pg.Tx, tSchema, sSchema model.ColSchema,
+func (s *Shifter) modifyDefault(tx Tx, tSchema, sSchema model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
 	isSame := isSameDefault(tSchema, sSchema)
@@ -463,9 +533,13 @@ func (s *Shifter) modifyDefault(tx *pg.Tx, tSchema, sSchema model.ColSchema,
 		} else {
 			sql = getSetDefaultSQL(sSchema.TableName, sSchema.ColumnName, sSchema.ColumnDefault)
 		}
-		if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-			err = getWrapError(sSchema.TableName, "modify default", sql, err)
+		c := newChange(ModifyDefaultChange, sSchema.TableName, sSchema.ColumnName, sql)
+		if tSchema.ColumnDefault == "" {
+			c.DownSQL = getDropDefaultSQL(tSchema.TableName, tSchema.ColumnName)
+		} else {
+			c.DownSQL = getSetDefaultSQL(tSchema.TableName, tSchema.ColumnName, tSchema.ColumnDefault)
 		}
+		isAlter, err = s.execByChoice(tx, c, skipPrompt)
 	}
 	return
 }
@@ -553,42 +627,42 @@ func getSetDefaultSQL(tName, cName, dVal string) (sql string) {
 }
 
 //modifyConstraint will modify primary key/ unique key/ foreign key constraints by comparing table and structure
-func (s *Shifter) modifyConstraint(tx *pg.Tx, tSchema, sSchema model.ColSchema,
+func (s *Shifter) modifyConstraint(tx Tx, tSchema, sSchema model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 
 	// fmt.Println(sSchema.ColumnName, "T", tSchema.IsFkUnique, tSchema.ConstraintType, "S", sSchema.IsFkUnique, sSchema.ConstraintType)
 	//if table and struct constraint doesn't match
 	if tSchema.ConstraintType != sSchema.ConstraintType {
 		if sSchema.ConstraintType == "" {
-			isAlter, err = dropColAllConstraints(tx, tSchema, sSchema, skipPrompt)
+			isAlter, err = s.dropColAllConstraints(tx, tSchema, sSchema, skipPrompt)
 		} else if tSchema.ConstraintType == "" {
-			isAlter, err = addColAllConstraints(tx, tSchema, sSchema, skipPrompt)
+			isAlter, err = s.addColAllConstraints(tx, tSchema, sSchema, skipPrompt)
 		} else {
-			isAlter, err = dropAndCreateConstraint(tx, tSchema, sSchema, skipPrompt)
+			isAlter, err = s.dropAndCreateConstraint(tx, tSchema, sSchema, skipPrompt)
 		}
 	} else if tSchema.ConstraintType == foreignKey {
-		isAlter, err = modifyFkAllConstraint(tx, tSchema, sSchema, skipPrompt)
+		isAlter, err = s.modifyFkAllConstraint(tx, tSchema, sSchema, skipPrompt)
 	}
 
 	if err == nil && isAlter == false {
-		isAlter, err = modifyDeferrable(tx, tSchema, sSchema, skipPrompt)
+		isAlter, err = s.modifyDeferrable(tx, tSchema, sSchema, skipPrompt)
 	}
 	return
 }
 
 //modifyFkAllConstraint will modify foreign key all constraints
-func modifyFkAllConstraint(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
+func (s *Shifter) modifyFkAllConstraint(tx Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
 	isAlter bool, err error) {
-	if isAlter, err = modifyFkUniqueConstraint(tx, tSchema, sSchema, skipPrompt); err == nil {
+	if isAlter, err = s.modifyFkUniqueConstraint(tx, tSchema, sSchema, skipPrompt); err == nil {
 		var curAlter bool
-		curAlter, err = modifyFkConstraint(tx, tSchema, sSchema, skipPrompt)
+		curAlter, err = s.modifyFkConstraint(tx, tSchema, sSchema, skipPrompt)
 		isAlter = isAlter || curAlter
 	}
 	return
 }
 
 //modifyFkConstraint will modify foreign key of column if changed
-func modifyFkConstraint(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
+func (s *Shifter) modifyFkConstraint(tx Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
 	isAlter bool, err error) {
 	//if foreign table or column changed
 	if tSchema.ForeignTableName != sSchema.ForeignTableName ||
@@ -596,34 +670,35 @@ func modifyFkConstraint(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt
 		tSchema.UpdateType != sSchema.UpdateType ||
 		tSchema.DeleteType != sSchema.DeleteType {
 
-		isAlter, err = dropAndCreateConstraint(tx, tSchema, sSchema, skipPrompt)
+		isAlter, err = s.dropAndCreateConstraint(tx, tSchema, sSchema, skipPrompt)
 	}
 	return
 }
 
 //dropAndCreateConstraint will drop current constraint and create new one
-func dropAndCreateConstraint(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
+func (s *Shifter) dropAndCreateConstraint(tx Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
 	isAlter bool, err error) {
 	fmt.Println("---dropping old and creating new constraint---")
-	if isAlter, err = dropColAllConstraints(tx, tSchema, sSchema, skipPrompt); err == nil {
+	if isAlter, err = s.dropColAllConstraints(tx, tSchema, sSchema, skipPrompt); err == nil {
 		var curAlter bool
-		curAlter, err = addColAllConstraints(tx, tSchema, sSchema, skipPrompt)
+		curAlter, err = s.addColAllConstraints(tx, tSchema, sSchema, skipPrompt)
 		isAlter = isAlter || curAlter
 	}
 	return
 }
 
 //dropColConstraints will drop column all constraints
-func dropColAllConstraints(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
+func (s *Shifter) dropColAllConstraints(tx Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
 	isAlter bool, err error) {
 
-	if isAlter, err = dropConstraint(tx, tSchema, skipPrompt); err == nil {
+	if isAlter, err = s.dropConstraint(tx, tSchema, skipPrompt); err == nil {
 		//TODO: also drop unique constraint if exists in table
 		//with foreign key
 		if tSchema.IsFkUnique && sSchema.IsFkUnique == false {
 			var curAtler bool
 			tSchema.ConstraintName = tSchema.FkUniqueName
-			curAtler, err = dropConstraint(tx, tSchema, skipPrompt)
+			tSchema.ConstraintType = uniqueKey
+			curAtler, err = s.dropConstraint(tx, tSchema, skipPrompt)
 			isAlter = isAlter || curAtler
 		}
 	}
@@ -632,30 +707,31 @@ func dropColAllConstraints(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipProm
 
 //modifyFkUniqueConstraint will modify unique key constraint
 //if exists with foreign key on same column
-func modifyFkUniqueConstraint(tx *pg.Tx, tSchema, sSchema model.ColSchema,
+func (s *Shifter) modifyFkUniqueConstraint(tx Tx, tSchema, sSchema model.ColSchema,
 	skipPrompt bool) (isAlter bool, err error) {
 	if tSchema.IsFkUnique != sSchema.IsFkUnique {
 		if sSchema.IsFkUnique {
 			//adding unique constraint in table
 			//as its exists with foreign key
 			sSchema.ConstraintType = uniqueKey
-			isAlter, err = addConstraint(tx, sSchema, skipPrompt)
+			isAlter, err = s.addConstraint(tx, sSchema, skipPrompt)
 		} else if tSchema.IsFkUnique {
 			//droping unique constraint from table
 			//as its not exists with foreign key in struct anymore
 			tSchema.ConstraintName = tSchema.FkUniqueName
-			isAlter, err = dropConstraint(tx, tSchema, skipPrompt)
+			tSchema.ConstraintType = uniqueKey
+			isAlter, err = s.dropConstraint(tx, tSchema, skipPrompt)
 		}
 	}
 	return
 }
 
 //dropConstraint will drop constraint from table
-func dropConstraint(tx *pg.Tx, tSchema model.ColSchema, skipPrompt bool) (isAlter bool, err error) {
+func (s *Shifter) dropConstraint(tx Tx, tSchema model.ColSchema, skipPrompt bool) (isAlter bool, err error) {
 	sql := getDropConstraintSQL(tSchema.TableName, tSchema.ConstraintName)
-	if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-		err = getWrapError(tSchema.TableName, "drop constraint", sql, err)
-	}
+	c := newChange(DropConstraintChange, tSchema.TableName, tSchema.ColumnName, sql)
+	c.DownSQL = getAlterAddConstraintSQL(tSchema)
+	isAlter, err = s.execByChoice(tx, c, skipPrompt)
 	return
 }
 
@@ -666,16 +742,16 @@ func getDropConstraintSQL(tName, constraintName string) (sql string) {
 }
 
 //addColAllConstraints will add column all constraints
-func addColAllConstraints(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
+func (s *Shifter) addColAllConstraints(tx Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
 	isAlter bool, err error) {
 
-	if isAlter, err = addConstraint(tx, sSchema, skipPrompt); err == nil {
+	if isAlter, err = s.addConstraint(tx, sSchema, skipPrompt); err == nil {
 		//TODO: also adding unique constraint if exists in struct
 		//with foreign key
 		if sSchema.IsFkUnique && tSchema.IsFkUnique == false {
 			var curAtler bool
 			sSchema.ConstraintType = uniqueKey
-			curAtler, err = addConstraint(tx, sSchema, skipPrompt)
+			curAtler, err = s.addConstraint(tx, sSchema, skipPrompt)
 			isAlter = isAlter || curAtler
 		}
 	}
@@ -683,13 +759,13 @@ func addColAllConstraints(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPromp
 }
 
 //addConstraint will add constraint on table column
-func addConstraint(tx *pg.Tx, schema model.ColSchema, skipPrompt bool) (
+func (s *Shifter) addConstraint(tx Tx, schema model.ColSchema, skipPrompt bool) (
 	isAlter bool, err error) {
 
 	sql := getAlterAddConstraintSQL(schema)
-	if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-		err = getWrapError(schema.TableName, "add constraint", sql, err)
-	}
+	c := newChange(AddConstraintChange, schema.TableName, schema.ColumnName, sql)
+	c.DownSQL = getDropConstraintSQL(schema.TableName, getConstraintName(schema))
+	isAlter, err = s.execByChoice(tx, c, skipPrompt)
 	return
 }
 
@@ -712,7 +788,7 @@ func getAddConstraintSQL(schema model.ColSchema) (sql string) {
 }
 
 //modifyDeferrable will modify add/drop constraint deferrable
-func modifyDeferrable(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
+func (s *Shifter) modifyDeferrable(tx Tx, tSchema, sSchema model.ColSchema, skipPrompt bool) (
 	isAlter bool, err error) {
 
 	// fmt.Println(tSchema.ColumnName, "T", tSchema.IsDeferrable, "S", sSchema.IsDeferrable)
@@ -721,9 +797,9 @@ func modifyDeferrable(tx *pg.Tx, tSchema, sSchema model.ColSchema, skipPrompt bo
 
 		sSchema.ConstraintName = tSchema.ConstraintName
 		sql := getDeferrableSQL(sSchema)
-		if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-			err = getWrapError(tSchema.TableName, "modify deferrable", sql, err)
-		}
+		c := newChange(ModifyDeferrableChange, tSchema.TableName, tSchema.ColumnName, sql)
+		c.DownSQL = getDeferrableSQL(tSchema)
+		isAlter, err = s.execByChoice(tx, c, skipPrompt)
 	}
 	return
 }
@@ -749,20 +825,35 @@ func getDeferrableSQL(schema model.ColSchema) (sql string) {
 
 //getWrapError will return wrapped error for better debugging
 func getWrapError(tName, op string, sql string, err error) (werr error) {
-	msg := fmt.Sprintf("%v %v error %v\nSQL: %v",
-		tName, op, err.Error(), sql)
-	werr = errors.New(msg)
+	//error is wrapped so that it can be checked using errors.Is i.e. ErrAborted
+	werr = fmt.Errorf("%v %v error %w\nSQL: %v", tName, op, err, sql)
 	return
 }
 
 //execByChoice will execute by choice
-func execByChoice(tx *pg.Tx, sql string, skipPrompt bool) (
+//if prompt is not skipped then change is confirmed by the prompter
+//in dry run the change is recorded without prompt
+//NoTx change is queued to execute after transaction is committed
+func (s *Shifter) execByChoice(tx Tx, c Change, skipPrompt bool) (
 	isAlter bool, err error) {
 
-	choice := util.GetChoice(sql, skipPrompt)
-	if choice == util.Yes {
+	d := Approve
+	if s.run.dryRun == false {
+		if s.policy.Allowed(c) == false {
+			//blocked change is skipped and reported on commit
+			s.run.blocked = append(s.run.blocked, c)
+			d = Skip
+		} else if skipPrompt == false {
+			d, err = s.confirm(c)
+		}
+	}
+	if err == nil && d == Approve {
 		isAlter = true
-		_, err = tx.Exec(sql)
+		if c.NoTx {
+			s.run.online = append(s.run.online, c)
+		} else {
+			err = s.exec(tx, c)
+		}
 	}
 	return
 }
@@ -825,7 +916,8 @@ func mergeColumnConstraint(tName string, columnSchema,
 	return ColSchema
 }
 
-//Debug : Print postgresql query on terminal
+//Debug : Print postgresql query of go-pg connection on terminal in verbose mode
+//queries executed by shifter are printed by its executor so it's needed only for other queries
 func (s *Shifter) Debug(conn *pg.DB) {
 	conn.OnQueryProcessed(func(event *pg.QueryProcessedEvent) {
 		if s.logSQL {
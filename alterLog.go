@@ -12,9 +12,9 @@ import (
 	"text/template"
 	"time"
 
-	"github.com/go-pg/pg"
 	"github.com/iancoleman/strcase"
 	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
 )
 
 //sLog : structure log model
@@ -24,6 +24,8 @@ type sLog struct {
 	TableName    string
 	Data         []model.ColSchema
 	Unique       []model.UKSchema
+	PrimaryKey   *model.KeySchema
+	ForeignKey   []model.KeySchema
 	Index        []model.Index
 	Date         string
 	importedPkg  map[string]struct{}
@@ -55,34 +57,38 @@ var pgToGoType = map[string]string{
 
 //createAlterStructLog will create alter struct log
 func (s *Shifter) createAlterStructLog(schema map[string]model.ColSchema,
-	ukSchema []model.UKSchema, idx []model.Index, wt bool) (err error) {
+	ukSchema []model.UKSchema, keySchema []model.KeySchema, idx []model.Index,
+	wt bool) (err error) {
 
 	var (
 		log   sLog
 		fData []byte
 	)
-	if log, fData, err = s.getTableStructSchema(schema, ukSchema, idx, wt); err == nil {
+	if log, fData, err = s.getTableStructSchema(schema, ukSchema, keySchema, idx, wt); err == nil {
 		err = s.logTableChange(log, fData)
 	}
 	return
 }
 
 //generateTableStructSchema will generate table schema from database in struct form
-func (s *Shifter) generateTableStructSchema(tx *pg.Tx, tableName string, wt bool) (
+func (s *Shifter) generateTableStructSchema(tx Tx, tableName string, wt bool) (
 	log sLog, fData []byte, exists bool, err error) {
 
 	var (
 		tUK     []model.UKSchema
+		tKey    []model.KeySchema
 		idx     []model.Index
 		tSchema map[string]model.ColSchema
 	)
 
-	exists = tableExists(tx, tableName)
+	exists = s.catalog(tx).TableExists(tableName)
 	if exists {
 		if tSchema, err = s.getTableSchema(tx, tableName); err == nil {
-			if tUK, err = getDBCompositeUniqueKey(tx, tableName); err == nil {
-				if idx, err = getDBIndex(tx, tableName); err == nil {
-					log, fData, err = s.getTableStructSchema(tSchema, tUK, idx, wt)
+			if tUK, err = s.catalog(tx).CompositeUniqueKey(tableName); err == nil {
+				if tKey, err = s.catalog(tx).CompositeKey(tableName); err == nil {
+					if idx, err = s.catalog(tx).Index(tableName); err == nil {
+						log, fData, err = s.getTableStructSchema(tSchema, tUK, tKey, idx, wt)
+					}
 				}
 			}
 		}
@@ -93,10 +99,11 @@ func (s *Shifter) generateTableStructSchema(tx *pg.Tx, tableName string, wt bool
 
 //getTableStructSchema will return table schema from database as in struct form
 func (s *Shifter) getTableStructSchema(schema map[string]model.ColSchema,
-	ukSchema []model.UKSchema, idx []model.Index, wt bool) (log sLog, fData []byte, err error) {
+	ukSchema []model.UKSchema, keySchema []model.KeySchema, idx []model.Index,
+	wt bool) (log sLog, fData []byte, err error) {
 
 	var logStr string
-	log = s.getSLogModel(schema, ukSchema, idx, wt)
+	log = s.getSLogModel(schema, ukSchema, keySchema, idx, wt)
 	if logStr, err = execLogTmpl(log); err == nil {
 
 		prefix := getWarning(wt) + getPkg(log.StructName) + getImportPkg(log.importedPkg)
@@ -131,11 +138,12 @@ func (s *Shifter) logTableChange(log sLog, logStr []byte) (err error) {
 
 //getSLogModel will return slog model
 func (s *Shifter) getSLogModel(schema map[string]model.ColSchema,
-	ukSchema []model.UKSchema, idx []model.Index, wt bool) (log sLog) {
+	ukSchema []model.UKSchema, keySchema []model.KeySchema, idx []model.Index,
+	wt bool) (log sLog) {
 
 	sTime := time.Now().UTC()
 	tName := getTableName(schema)
-	sName := getFieldName(tName)
+	sName := getFieldName(util.GetBareName(tName))
 	if model, exists := s.table[tName]; exists {
 		sName = getTableNameFromStruct(model)
 	}
@@ -155,6 +163,14 @@ func (s *Shifter) getSLogModel(schema map[string]model.ColSchema,
 		Date:         sTime.Format("Mon _2 Jan 2006 15:04:05"),
 		importedPkg:  make(map[string]struct{}),
 	}
+	for i, key := range keySchema {
+		if key.ConstraintType == primaryKey {
+			log.PrimaryKey = &keySchema[i]
+		} else {
+			log.ForeignKey = append(log.ForeignKey, key)
+			log.importedPkg[modelPkg] = struct{}{}
+		}
+	}
 	return
 }
 
@@ -172,6 +188,7 @@ func getImportPkg(pkg map[string]struct{}) (impPkg string) {
 }
 
 //makeStructLogDir will create struct log dir if not exists
+//log dir of shifter schema is under the schema dir i.e. log/tenant_1/TestUser
 func (s *Shifter) makeStructLogDir(structName string) (logDir string, err error) {
 	if s.logPath == "" {
 		s.logPath, err = os.Getwd()
@@ -179,6 +196,10 @@ func (s *Shifter) makeStructLogDir(structName string) (logDir string, err error)
 	}
 	if err == nil {
 		logDir = s.logPath + "/" + structName
+		if s.schema != "" {
+			//struct of each schema altered by AlterAllSchemas is logged separately
+			logDir = s.logPath + "/" + s.schema + "/" + structName
+		}
 		if _, err = os.Stat(logDir); os.IsNotExist(err) {
 			err = os.MkdirAll(logDir, os.ModePerm)
 		}
@@ -207,11 +228,27 @@ func execLogTmpl(log sLog) (logStr string, err error) {
 //getTmplFunc will return template functions
 func getLogTmplFunc() template.FuncMap {
 	return template.FuncMap{
-		"Title":     getFieldName,
-		"getSQLTag": getSQLTag,
+		"Title":         getFieldName,
+		"getSQLTag":     getSQLTag,
+		"getKeyColumns": getKeyColumns,
+		"getKeyAction":  getKeyAction,
 	}
 }
 
+//getKeyColumns will return comma separated key columns as quoted string list
+func getKeyColumns(columns string) (list string) {
+	if columns != "" {
+		list = `"` + strings.Replace(columns, ",", `", "`, -1) + `"`
+	}
+	return
+}
+
+//getKeyAction will return on delete/update action of foreign key flag
+func getKeyAction(flag string) (action string) {
+	action = strings.ToLower(getConstraintTagByFlag(flag))
+	return
+}
+
 //getSQLTag will return struct sql tag from schema struct
 func getSQLTag(schema model.ColSchema) (dType string) {
 	dType = getStructDataType(schema)
@@ -327,6 +364,33 @@ func ({{ .StructNameWT }}) UniqueKey() []string {
 }
 {{ end }}
 
+{{ if .PrimaryKey }}
+//PrimaryKey of the table. This is for composite primary key
+func ({{ .StructNameWT }}) PrimaryKey() []string {
+	return []string{ {{- getKeyColumns .PrimaryKey.Columns -}} } //{{ .PrimaryKey.ConstraintName }}
+}
+{{ end }}
+
+{{ $length := len .ForeignKey }} {{ if gt $length 0 }}
+//ForeignKeys of the table. This is for multi column foreign keys
+func ({{ .StructNameWT }}) ForeignKeys() []model.ForeignKey {
+	fk := []model.ForeignKey{
+		{{- range $key, $value := .ForeignKey}}
+		{ //{{ $value.ConstraintName }}
+			Columns:           []string{ {{- getKeyColumns $value.Columns -}} },
+			RefTable:          "{{ $value.ForeignTableName }}",
+			RefColumns:        []string{ {{- getKeyColumns $value.ForeignColumns -}} },
+			OnDelete:          "{{ getKeyAction $value.DeleteType }}",
+			OnUpdate:          "{{ getKeyAction $value.UpdateType }}",
+			Deferrable:        {{ eq $value.IsDeferrable "YES" }},
+			InitiallyDeferred: {{ eq $value.InitiallyDeferred "YES" }},
+		},
+		{{- end }}
+	}
+	return fk
+}
+{{ end }}
+
 {{ $length := len .Index }} {{ if gt $length 0 }}
 //Index of the table. For composite index use ,
 //Default index type is btree. For gin index use gin value
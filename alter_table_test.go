@@ -34,7 +34,7 @@ func TestAlterColumnTypeModify(t *testing.T) {
 			err = s.AlterTable(conn, curStruct, true)
 			assert.NoError(err)
 		}
-		err = s.DropTable(conn, &TestTable{}, true)
+		err = s.DropTable(conn, &TestTable{}, true, true)
 		assert.NoError(err)
 	}
 }
@@ -9,7 +9,8 @@ import (
 )
 
 func TestLocalAlterTable(t *testing.T) {
-	if tx, err := psql.Tx(); err == nil {
+	if pgtx, err := psql.Tx(); err == nil {
+		tx := pgTx{tx: pgtx}
 		s := NewShifter()
 		assert := assert.New(t)
 
@@ -52,6 +53,11 @@ func TestLocalAlterTable(t *testing.T) {
 		err = s.alterTable(tx, tName, true)
 		assert.NoError(err)
 
+		//rename column
+		s.SetTableModel(&localUserColRenamed{})
+		err = s.alterTable(tx, tName, true)
+		assert.NoError(err)
+
 		//col unique added
 		s.SetTableModel(&localUserUnqCol{})
 		err = s.alterTable(tx, tName, true)
@@ -107,7 +113,7 @@ func TestLocalAlterTable(t *testing.T) {
 		err = s.alterTable(tx, tName, true)
 		assert.NoError(err)
 
-		err = s.dropTable(tx, tName, true)
+		err = s.dropTable(tx, tName, true, true)
 		assert.NoError(err)
 	}
 }
@@ -146,6 +152,22 @@ type localUserDefaultRemoved struct {
 	UpdatedAt time.Time `sql:"updated_at,type:timestamp NOT NULL DEFAULT NOW()"`
 }
 
+type localUserColRenamed struct {
+	tableName struct{}  `sql:"local_user"`
+	UserID    int       `sql:"user_id,type:serial PRIMARY KEY"`
+	Passcode  string    `sql:"passcode,type:varchar(255) NULL DEFAULT NULL"`
+	CreatedBy int       `sql:"created_by,type:int"`
+	CreatedAt time.Time `sql:"created_at,type:timestamp NOT NULL DEFAULT NOW()"`
+	UpdatedAt time.Time `sql:"updated_at,type:timestamp NOT NULL DEFAULT NOW()"`
+}
+
+//Renames of the table. Key is new column name and value is old column name
+func (localUserColRenamed) Renames() map[string]string {
+	return map[string]string{
+		"passcode": "password",
+	}
+}
+
 type localUserUnqCol struct {
 	tableName struct{}  `sql:"local_user"`
 	UserID    int       `sql:"user_id,type:serial PRIMARY KEY"`
@@ -0,0 +1,110 @@
+package shifter
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/go-pg/pg/orm"
+)
+
+var errCaptureOnly = errors.New("sql capture doesn't support query execution")
+
+//sqlCapture implements orm.DB and captures the sql
+//generated by go-pg orm instead of executing it
+type sqlCapture struct {
+	sql   string
+	fmter orm.Formatter
+}
+
+//getCreateTableSQL will return create table sql of table model
+//which go-pg will execute on CreateTable
+func getCreateTableSQL(tableModel interface{}) (sql string, err error) {
+	capture := &sqlCapture{}
+	if err = orm.CreateTable(capture, tableModel,
+		&orm.CreateTableOptions{IfNotExists: true}); err == nil {
+		sql = capture.sql + ";\n"
+	}
+	return
+}
+
+//Exec will capture the query
+func (c *sqlCapture) Exec(query interface{}, params ...interface{}) (res orm.Result, err error) {
+	switch q := query.(type) {
+	case orm.QueryAppender:
+		var b []byte
+		if b, err = q.AppendQuery(nil); err == nil {
+			c.sql = string(b)
+		}
+	case string:
+		c.sql = string(c.FormatQuery(nil, q, params...))
+	default:
+		err = errCaptureOnly
+	}
+	return
+}
+
+//ExecOne will capture the query
+func (c *sqlCapture) ExecOne(query interface{}, params ...interface{}) (orm.Result, error) {
+	return c.Exec(query, params...)
+}
+
+//FormatQuery will format the query using default go-pg formatter
+func (c *sqlCapture) FormatQuery(b []byte, query string, params ...interface{}) []byte {
+	return c.fmter.FormatQuery(b, query, params...)
+}
+
+//Context will return background context
+func (c *sqlCapture) Context() context.Context {
+	return context.Background()
+}
+
+//Model will return orm query of the model
+func (c *sqlCapture) Model(model ...interface{}) *orm.Query {
+	return orm.NewQuery(c, model...)
+}
+
+//Select is not supported by sql capture
+func (c *sqlCapture) Select(model interface{}) error {
+	return errCaptureOnly
+}
+
+//Insert is not supported by sql capture
+func (c *sqlCapture) Insert(model ...interface{}) error {
+	return errCaptureOnly
+}
+
+//Update is not supported by sql capture
+func (c *sqlCapture) Update(model interface{}) error {
+	return errCaptureOnly
+}
+
+//Delete is not supported by sql capture
+func (c *sqlCapture) Delete(model interface{}) error {
+	return errCaptureOnly
+}
+
+//ForceDelete is not supported by sql capture
+func (c *sqlCapture) ForceDelete(model interface{}) error {
+	return errCaptureOnly
+}
+
+//Query is not supported by sql capture
+func (c *sqlCapture) Query(coll, query interface{}, params ...interface{}) (orm.Result, error) {
+	return nil, errCaptureOnly
+}
+
+//QueryOne is not supported by sql capture
+func (c *sqlCapture) QueryOne(model, query interface{}, params ...interface{}) (orm.Result, error) {
+	return nil, errCaptureOnly
+}
+
+//CopyFrom is not supported by sql capture
+func (c *sqlCapture) CopyFrom(r io.Reader, query interface{}, params ...interface{}) (orm.Result, error) {
+	return nil, errCaptureOnly
+}
+
+//CopyTo is not supported by sql capture
+func (c *sqlCapture) CopyTo(w io.Writer, query interface{}, params ...interface{}) (orm.Result, error) {
+	return nil, errCaptureOnly
+}
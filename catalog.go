@@ -0,0 +1,109 @@
+package shifter
+
+import (
+	"github.com/mayur-tolexo/pg-shifter/model"
+)
+
+//Catalog is the source of existing database schema which is compared with table structs.
+//Live catalog reads the database using the transaction of shifter
+//and Snapshot reads the schema dumped in a json/yaml file
+type Catalog interface {
+	//TableExists will check table exists
+	TableExists(tableName string) bool
+	//ColumnSchema will return columns of table
+	ColumnSchema(tableName string) ([]model.ColSchema, error)
+	//Constraint will return single column primary, foreign and unique keys of table
+	Constraint(tableName string) ([]model.ColSchema, error)
+	//CompositeKey will return composite primary and foreign keys of table
+	CompositeKey(tableName string) ([]model.KeySchema, error)
+	//CompositeUniqueKey will return composite unique keys of table
+	CompositeUniqueKey(tableName string) ([]model.UKSchema, error)
+	//Check will return check constraints of table
+	Check(tableName string) ([]model.CheckSchema, error)
+	//Index will return valid non unique indexes of table
+	Index(tableName string) ([]model.Index, error)
+	//InvalidIndex will return invalid index names of table
+	InvalidIndex(tableName string) ([]string, error)
+	//Trigger will return trigger names of table
+	Trigger(tableName string) ([]string, error)
+	//EnumExists will check enum type exists
+	EnumExists(enumName string) bool
+	//EnumValue will return values of enum type
+	EnumValue(enumName string) ([]string, error)
+	//VolatileFunction will check any of the given functions is volatile
+	VolatileFunction(funcs []string) (bool, error)
+}
+
+//liveCatalog reads the schema from database using the transaction
+type liveCatalog struct {
+	tx Tx
+}
+
+//catalog will return catalog of the run
+//if offline catalog is not set then database is read using the transaction
+func (s *Shifter) catalog(tx Tx) Catalog {
+	if s.run.catalog != nil {
+		return s.run.catalog
+	}
+	return liveCatalog{tx: tx}
+}
+
+//TableExists will check table exists in database
+func (c liveCatalog) TableExists(tableName string) bool {
+	return tableExists(c.tx, tableName)
+}
+
+//ColumnSchema will return columns of table from database
+func (c liveCatalog) ColumnSchema(tableName string) ([]model.ColSchema, error) {
+	return getColumnSchema(c.tx, tableName)
+}
+
+//Constraint will return single column constraints of table from database
+func (c liveCatalog) Constraint(tableName string) ([]model.ColSchema, error) {
+	return getConstraint(c.tx, tableName)
+}
+
+//CompositeKey will return composite primary and foreign keys of table from database
+func (c liveCatalog) CompositeKey(tableName string) ([]model.KeySchema, error) {
+	return getDBCompositeKey(c.tx, tableName)
+}
+
+//CompositeUniqueKey will return composite unique keys of table from database
+func (c liveCatalog) CompositeUniqueKey(tableName string) ([]model.UKSchema, error) {
+	return getDBCompositeUniqueKey(c.tx, tableName)
+}
+
+//Check will return check constraints of table from database
+func (c liveCatalog) Check(tableName string) ([]model.CheckSchema, error) {
+	return getDBCheck(c.tx, tableName)
+}
+
+//Index will return indexes of table from database
+func (c liveCatalog) Index(tableName string) ([]model.Index, error) {
+	return getDBIndex(c.tx, tableName)
+}
+
+//InvalidIndex will return invalid indexes of table from database
+func (c liveCatalog) InvalidIndex(tableName string) ([]string, error) {
+	return getDBInvalidIndex(c.tx, tableName)
+}
+
+//Trigger will return trigger names of table from database
+func (c liveCatalog) Trigger(tableName string) ([]string, error) {
+	return getDBTrigger(c.tx, tableName)
+}
+
+//EnumExists will check enum type exists in database
+func (c liveCatalog) EnumExists(enumName string) bool {
+	return dbEnumExists(c.tx, enumName)
+}
+
+//EnumValue will return values of enum type from database
+func (c liveCatalog) EnumValue(enumName string) ([]string, error) {
+	return getDBEnumValue(c.tx, enumName)
+}
+
+//VolatileFunction will check any of the given functions is volatile in database
+func (c liveCatalog) VolatileFunction(funcs []string) (bool, error) {
+	return getVolatileFunction(c.tx, funcs)
+}
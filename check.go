@@ -0,0 +1,201 @@
+package shifter
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//castRegex matches type casts added by postgresql in constraint definition
+//i.e. (0)::numeric or 'a'::character varying
+var castRegex = regexp.MustCompile(`::\s*"?[a-z_][a-z0-9_]*"?` +
+	`(\s+(varying|precision|without\s+time\s+zone|with\s+time\s+zone))?` +
+	`(\s*\(\s*\d+(\s*,\s*\d+)?\s*\))?(\[\])*`)
+
+//getCheckFromStruct will return check constraints of struct by constraint name
+//defined in column sql tag check option and Check() method
+func (s *Shifter) getCheckFromStruct(tName string) (check map[string]string) {
+	dbModel := s.table[tName]
+	check = make(map[string]string)
+	for _, field := range util.GetStructField(dbModel) {
+		tag := field.Tag.Get("sql")
+		if expr := getCheckFromTag(tag); expr != "" {
+			colName := getColName(strings.ToLower(tag))
+			name := fmt.Sprintf("%v_%v_%v", util.GetBareName(tName), colName, checkSuffix)
+			check[util.GetUniqueStrByLen(name, 64)] = expr
+		}
+	}
+
+	refObj := reflect.ValueOf(dbModel)
+	m := refObj.MethodByName("Check")
+	if m.IsValid() {
+		out := m.Call([]reflect.Value{})
+		if len(out) > 0 && out[0].Kind() == reflect.Map {
+			if val, ok := out[0].Interface().(map[string]string); ok {
+				for name, expr := range val {
+					check[name] = strings.TrimSpace(expr)
+				}
+			}
+		}
+	}
+	return
+}
+
+//getCheckFromTag will return check expression from struct sql tag
+//i.e. check:(price > 0) will return price > 0
+func getCheckFromTag(tag string) (expr string) {
+	if idx := strings.Index(strings.ToLower(tag), checkTag); idx >= 0 {
+		expr = strings.TrimSpace(tag[idx+len(checkTag):])
+		if strings.HasPrefix(expr, "(") {
+			depth := 0
+			for i, ch := range expr {
+				if ch == '(' {
+					depth++
+				} else if ch == ')' {
+					depth--
+					if depth == 0 {
+						expr = expr[1:i]
+						break
+					}
+				}
+			}
+		} else {
+			expr = strings.Split(expr, ",")[0]
+		}
+		expr = strings.TrimSpace(expr)
+	}
+	return
+}
+
+//normalizeCheck will normalize check expression so that struct expression
+//can be compared with postgresql constraint definition
+//i.e. CHECK ((price > (0)::numeric)) will be price>0
+func normalizeCheck(def string) (expr string) {
+	expr = strings.ToLower(strings.TrimSpace(def))
+	expr = strings.TrimSuffix(expr, "not valid")
+	expr = strings.TrimPrefix(expr, "check")
+	expr = castRegex.ReplaceAllString(expr, "")
+	expr = strings.NewReplacer("(", "", ")", "", " ", "", "\t", "", "\n", "").Replace(expr)
+	return
+}
+
+//getCreateCheckSQL will add struct check constraints in create table sql
+func (s *Shifter) getCreateCheckSQL(tableName, sql string) string {
+	check := s.getCheckFromStruct(tableName)
+	if idx := strings.LastIndex(sql, ")"); idx >= 0 && len(check) > 0 {
+		checkSQL := ""
+		for _, name := range getSortedCheckName(check) {
+			checkSQL += fmt.Sprintf(", CONSTRAINT %v CHECK (%v)", name, check[name])
+		}
+		sql = sql[:idx] + checkSQL + sql[idx:]
+	}
+	return sql
+}
+
+//dropCheck will drop check constraints which are modified in struct
+//returned struct checks are the checks need to be added.
+//Checks not declared in struct i.e. inline check in column type or
+//not null check of online alter are not managed so those are kept
+func (s *Shifter) dropCheck(tx Tx, tName string, skipPrompt bool) (
+	sCheck map[string]string, isAlter bool, err error) {
+
+	var tCheck []model.CheckSchema
+	defer func() { s.logMode(false) }()
+	sCheck = s.getCheckFromStruct(tName)
+	if tCheck, err = s.catalog(tx).Check(tName); err == nil {
+		s.logMode(s.verbose)
+		for _, curTableCheck := range tCheck {
+			var curAlter bool
+			expr, exists := sCheck[curTableCheck.ConstraintName]
+			if exists == false {
+				//not null check left by failed online alter is validated by setNotNullOnline
+				continue
+			} else if normalizeCheck(expr) == normalizeCheck(curTableCheck.Definition) {
+				//check is not modified
+				delete(sCheck, curTableCheck.ConstraintName)
+				if isNotValid(curTableCheck.Definition) {
+					//validation of check left by failed alter is queued again
+					s.queueValidate(tName, "", curTableCheck.ConstraintName)
+				}
+			} else {
+				//check is modified in struct
+				//so dropping it and modified check will be created again
+				sql := getDropConstraintSQL(tName, curTableCheck.ConstraintName)
+				c := newChange(DropCheckChange, tName, "", sql)
+				c.DownSQL = fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v %v;\n",
+					tName, curTableCheck.ConstraintName, curTableCheck.Definition)
+				if curAlter, err = s.execByChoice(tx, c, skipPrompt); err != nil {
+					break
+				}
+			}
+			isAlter = isAlter || curAlter
+		}
+	}
+	return
+}
+
+//addCheck will add check constraints which are not in table
+//check is added as not valid and validation is queued as online step
+//which is executed in its own transaction after alter is committed
+//so that writes are not blocked while validating existing rows
+func (s *Shifter) addCheck(tx Tx, tName string, sCheck map[string]string,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	defer func() { s.logMode(false) }()
+	s.logMode(s.verbose)
+	for _, name := range getSortedCheckName(sCheck) {
+		var curAlter bool
+		c := newChange(AddCheckChange, tName, "", getAddCheckSQL(tName, name, sCheck[name]))
+		c.DownSQL = getDropConstraintSQL(tName, name)
+		if curAlter, err = s.execByChoice(tx, c, skipPrompt); err != nil {
+			break
+		} else if curAlter {
+			s.queueValidate(tName, "", name)
+		}
+		isAlter = isAlter || curAlter
+	}
+	return
+}
+
+//getAddCheckSQL will return add check constraint sql
+func getAddCheckSQL(tName, name, expr string) (sql string) {
+	sql = fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v CHECK (%v) NOT VALID;\n",
+		tName, name, expr)
+	return
+}
+
+//queueValidate will queue the online step to validate the not valid constraint
+func (s *Shifter) queueValidate(tName, cName, name string) {
+	sql := fmt.Sprintf("ALTER TABLE %v VALIDATE CONSTRAINT %v;\n", tName, name)
+	s.run.online = append(s.run.online, newChange(ValidateChange, tName, cName, sql))
+}
+
+//isNotValid will check constraint definition is not validated
+func isNotValid(def string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSpace(def)), "not valid")
+}
+
+//getSortedCheckName will return check constraint names in sorted order
+func getSortedCheckName(check map[string]string) (names []string) {
+	for name := range check {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+//getDBCheck : Get check constraints of table from database
+func getDBCheck(tx Tx, tableName string) (check []model.CheckSchema, err error) {
+	query := `SELECT conname, pg_get_constraintdef(oid) AS def
+	FROM pg_constraint WHERE conrelid = ?::regclass::oid AND contype = 'c'
+	ORDER BY conname;`
+	if err = tx.Query(&check, query, tableName); err != nil {
+		err = getWrapError(tableName, "check constraint", query, err)
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type localProduct struct {
+	tableName struct{} `sql:"local_product"`
+	ProductID int      `sql:"product_id,type:serial PRIMARY KEY"`
+	Price     float64  `sql:"price,type:numeric NOT NULL,check:(price > 0)"`
+	Discount  float64  `sql:"discount,type:numeric NULL"`
+	Status    string   `sql:"status,type:varchar(10) NOT NULL"`
+}
+
+//Check of the table
+func (localProduct) Check() map[string]string {
+	return map[string]string{
+		"local_product_discount_check": "discount <= price",
+	}
+}
+
+func TestGetCheckFromTag(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("price > 0", getCheckFromTag("price,type:numeric NOT NULL,check:(price > 0)"))
+	assert.Equal("length(name) > 2 AND name <> 'Admin'",
+		getCheckFromTag("name,check:(length(name) > 2 AND name <> 'Admin'),type:text"))
+	assert.Equal("", getCheckFromTag("check_in,type:date"))
+}
+
+func TestNormalizeCheck(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(normalizeCheck("price > 0"), normalizeCheck("CHECK ((price > (0)::numeric))"))
+	assert.Equal(normalizeCheck("status <> 'closed'"),
+		normalizeCheck("CHECK (((status)::text <> 'closed'::text)) NOT VALID"))
+	assert.Equal(normalizeCheck("price > 0 AND price < 100"),
+		normalizeCheck("CHECK (((price > (0)::numeric) AND (price < (100)::numeric)))"))
+	assert.NotEqual(normalizeCheck("price > 1"), normalizeCheck("CHECK ((price > (0)::numeric))"))
+}
+
+func TestCheckFromStruct(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&localProduct{})
+	assert.Equal(map[string]string{
+		"local_product_price_check":    "price > 0",
+		"local_product_discount_check": "discount <= price",
+	}, s.getCheckFromStruct("local_product"))
+
+	sql, err := getCreateTableSQL(&localProduct{})
+	assert.NoError(err)
+	sql = s.getCreateCheckSQL("local_product", sql)
+	assert.Contains(sql, ", CONSTRAINT local_product_discount_check CHECK (discount <= price), "+
+		"CONSTRAINT local_product_price_check CHECK (price > 0));\n")
+
+	assert.Equal("ALTER TABLE local_product ADD CONSTRAINT local_product_price_check CHECK (price > 0) NOT VALID;\n",
+		getAddCheckSQL("local_product", "local_product_price_check", "price > 0"))
+}
+
+func TestAlterCheck(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&localProduct{})
+	s.run.dryRun = true
+	s.run.catalog = Snapshot{Tables: map[string]TableSnapshot{"local_product": {
+		Checks: []model.CheckSchema{
+			//modified check
+			{ConstraintName: "local_product_discount_check",
+				Definition: "CHECK ((discount < price))"},
+			//inline check of column type is not managed
+			{ConstraintName: "local_product_status_check",
+				Definition: "CHECK (((status)::text <> ''::text))"},
+		}}}}
+	sCheck, isAlter, err := s.dropCheck(nil, "local_product", true)
+	assert.NoError(err)
+	assert.True(isAlter)
+	if assert.Len(s.run.changes, 1) {
+		assert.Equal(DropCheckChange, s.run.changes[0].Kind)
+		assert.Contains(s.run.changes[0].SQL, "local_product_discount_check")
+	}
+
+	//check is validated after alter is committed
+	isAlter, err = s.addCheck(nil, "local_product", sCheck, true)
+	assert.NoError(err)
+	assert.True(isAlter)
+	assert.Len(s.run.changes, 3)
+	assert.NotContains(s.run.changes[1].SQL, "VALIDATE")
+	if assert.Len(s.run.online, 2) {
+		assert.Equal(ValidateChange, s.run.online[0].Kind)
+		assert.Equal("ALTER TABLE local_product VALIDATE CONSTRAINT local_product_discount_check;\n",
+			s.run.online[0].SQL)
+	}
+
+	//not valid check left by failed validation is validated again
+	s = NewShifter(&localProduct{})
+	s.run.dryRun = true
+	s.run.catalog = Snapshot{Tables: map[string]TableSnapshot{"local_product": {
+		Checks: []model.CheckSchema{
+			{ConstraintName: "local_product_discount_check",
+				Definition: "CHECK ((discount <= price)) NOT VALID"},
+			{ConstraintName: "local_product_price_check",
+				Definition: "CHECK ((price > (0)::numeric))"},
+		}}}}
+	sCheck, isAlter, err = s.dropCheck(nil, "local_product", true)
+	assert.NoError(err)
+	assert.False(isAlter)
+	assert.Empty(sCheck)
+	if assert.Len(s.run.online, 1) {
+		assert.Contains(s.run.online[0].SQL, "VALIDATE CONSTRAINT local_product_discount_check")
+	}
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(alterCmd)
+}
+
+var alterCmd = &cobra.Command{
+	Use:   "alter [table...]",
+	Short: "Alter Table",
+	Long: `This will alter tables by comparing with struct.
+Before executing each change it will prompt for confirmation unless --yes is passed.
+For altering all registered tables use --all
+i.e ./shifter alter --all --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, true, printPlan, func(conn *pg.DB, tableName string) error {
+			return shift.AlterTable(conn, tableName, opt.yes)
+		})
+	},
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+//checkJSON will print the drift report in json
+var checkJSON bool
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkJSON, "json", false, "print drift report in json")
+	rootCmd.AddCommand(checkCmd)
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check [table...]",
+	Short: "Check Drift",
+	Long: `This will compare the registered table structs with database
+and print the differences without changing anything in database.
+Exits with non-zero status if database has drifted.
+i.e ./shifter check --all --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opt.dryRun = true
+		return runTables(args, true, printDrift, func(conn *pg.DB, tableName string) error {
+			return nil
+		})
+	},
+}
+
+//printDrift will print the drift report of tables
+//error is returned if there is any drift so that command exits with non-zero status
+func printDrift(conn *pg.DB, tables []string) (err error) {
+	var (
+		report shifter.DriftReport
+		data   []byte
+	)
+	if report, err = shift.Drift(conn, getModels(tables)...); err == nil {
+		if checkJSON {
+			if data, err = report.JSON(); err == nil {
+				fmt.Fprintln(stdout, string(data))
+			}
+		} else {
+			fmt.Fprint(stdout, report.Text())
+		}
+		if err == nil && report.HasDrift() {
+			err = fmt.Errorf("database has drifted from table structs in %v places", len(report.Drift))
+		}
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//dbConfig is the database connection config
+type dbConfig struct {
+	Engine   string `yaml:"engine"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	DB       string `yaml:"db"`
+}
+
+//config is the yaml config file model
+//migrations are executed on master so slaves are ignored
+type config struct {
+	Database struct {
+		Master dbConfig `yaml:"master"`
+	} `yaml:"database"`
+}
+
+//loadConfig will load database config from yaml file
+func loadConfig(path string) (dbConf dbConfig, err error) {
+	var (
+		data []byte
+		conf config
+	)
+	if data, err = ioutil.ReadFile(path); err == nil {
+		if err = yaml.Unmarshal(data, &conf); err == nil {
+			dbConf = conf.Database.Master
+			if dbConf.Engine != "" && dbConf.Engine != "postgres" {
+				err = fmt.Errorf("%v engine %v is not supported", path, dbConf.Engine)
+			} else if dbConf.Host == "" || dbConf.DB == "" {
+				err = fmt.Errorf("%v database.master host and db are required", path)
+			}
+		}
+	}
+	return
+}
+
+//connect will connect to master database of config file
+func connect() (conn *pg.DB, err error) {
+	var dbConf dbConfig
+	if dbConf, err = loadConfig(opt.config); err == nil {
+		port := dbConf.Port
+		if port == "" {
+			port = "5432"
+		}
+		conn = pg.Connect(&pg.Options{
+			Addr:     net.JoinHostPort(dbConf.Host, port),
+			User:     dbConf.Username,
+			Password: dbConf.Password,
+			Database: dbConf.DB,
+		})
+		if _, err = conn.Exec("SELECT 1"); err != nil {
+			conn.Close()
+			conn = nil
+		} else {
+			if opt.schema != "" {
+				shift.Schema(opt.schema)
+			}
+			shift.SetLockTimeout(opt.lockTimeout).SetStatementTimeout(opt.stmtTimeout)
+			shift.SetRetry(shifter.RetryPolicy{Attempts: opt.retry, Backoff: opt.backoff})
+			shift.AdvisoryLock(opt.lockKey, opt.lockWait)
+		}
+	}
+	return
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "shifter")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dev.yaml")
+	data := "database:\n  master:\n    engine: postgres\n    username: postgres\n" +
+		"    host: localhost\n    port: \"5433\"\n    db: shifter\n"
+	assert.NoError(ioutil.WriteFile(path, []byte(data), 0644))
+	conf, err := loadConfig(path)
+	assert.NoError(err)
+	assert.Equal(dbConfig{Engine: "postgres", Username: "postgres", Host: "localhost",
+		Port: "5433", DB: "shifter"}, conf)
+
+	assert.NoError(ioutil.WriteFile(path, []byte("database:\n  master:\n    engine: mysql\n"), 0644))
+	_, err = loadConfig(path)
+	assert.EqualError(err, path+" engine mysql is not supported")
+
+	assert.NoError(ioutil.WriteFile(path, []byte("database:\n  master:\n    db: shifter\n"), 0644))
+	_, err = loadConfig(path)
+	assert.EqualError(err, path+" database.master host and db are required")
+
+	_, err = loadConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(err)
+}
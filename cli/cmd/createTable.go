@@ -1,22 +1,42 @@
 package cmd
 
 import (
-	"fmt"
-
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
 	"github.com/spf13/cobra"
 )
 
+//createKinds are the change kinds executed by create command
+//alter changes of existing table are not executed by it
+var createKinds = []shifter.ChangeKind{
+	shifter.CreateSchemaChange, shifter.CreateEnumChange, shifter.AddEnumValueChange,
+	shifter.CreateTableChange, shifter.AddCompositeKeyChange, shifter.CreateHistoryChange,
+	shifter.PostCreateChange, shifter.CreateTriggerChange, shifter.CreateIndexChange,
+	shifter.AddUniqueKeyChange,
+}
+
 func init() {
-	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(createCmd)
 }
 
-var versionCmd = &cobra.Command{
-	Use:   "ct",
+var createCmd = &cobra.Command{
+	Use:   "create [table...]",
 	Short: "Create Table",
-	Long: `This will allow you to create table. Pass table names space separeted.
-For creating all tables pass all as argument
-i.e ./shifter ct all`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Hugo Static Site Generator v0.9 -- HEAD", args)
+	Long: `This will create tables with their enum, index and composite unique key
+if not exists. Pass table names space separated or use --tables.
+For creating all registered tables use --all
+i.e ./shifter create --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, true, printPlanOf(createKinds...), createTable)
 	},
 }
+
+//createTable will create table with index and composite unique key
+func createTable(conn *pg.DB, tableName string) (err error) {
+	if err = shift.CreateTable(conn, tableName); err == nil {
+		if err = shift.CreateAllIndex(conn, tableName, true); err == nil {
+			err = shift.CreateAllUniqueKey(conn, tableName, true)
+		}
+	}
+	return
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+)
+
+//ddlOut is the file in which ddl is written
+var ddlOut string
+
+func init() {
+	ddlCmd.Flags().StringVarP(&ddlOut, "out", "o", "", "sql file, printed on stdout if not given")
+	rootCmd.AddCommand(ddlCmd)
+}
+
+var ddlCmd = &cobra.Command{
+	Use:   "ddl [table...]",
+	Short: "Export Schema DDL",
+	Long: `This will export the sql which creates the tables from scratch
+including enums, constraints, composite keys, unique keys, indexes,
+history tables and triggers in dependency order.
+Database connection is not needed so schema.sql can be committed and reviewed.
+i.e ./shifter ddl --all --out schema.sql`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeDDL(args)
+	},
+}
+
+//writeDDL will write the ddl of tables in ddl file or stdout
+func writeDDL(args []string) (err error) {
+	var (
+		tables []string
+		ddl    string
+	)
+	if tables, err = getTables(args, true); err == nil {
+		if opt.schema != "" {
+			shift.Schema(opt.schema)
+		}
+		if ddl, err = shift.DDL(getModels(tables)...); err == nil {
+			if ddlOut == "" {
+				fmt.Fprint(stdout, ddl)
+			} else if err = ioutil.WriteFile(ddlOut, []byte(ddl), 0644); err == nil {
+				fmt.Fprintf(stdout, "DDL of %v tables written in %v\n", len(tables), ddlOut)
+			}
+		}
+	}
+	return
+}
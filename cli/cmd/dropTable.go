@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+var cascade bool
+
+func init() {
+	dropCmd.Flags().BoolVar(&cascade, "cascade", false, "drop table with cascade")
+	rootCmd.AddCommand(dropCmd)
+}
+
+var dropCmd = &cobra.Command{
+	Use:   "drop [table...]",
+	Short: "Drop Table",
+	Long: `This will drop tables and their history tables if exists.
+Before dropping each table it will prompt for confirmation unless --yes is passed.
+i.e ./shifter drop test_address --cascade`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, true, printDrop, dropTable)
+	},
+}
+
+//dropTable will drop table after confirmation unless --yes is passed
+func dropTable(conn *pg.DB, tableName string) error {
+	return shift.DropTable(conn, tableName, cascade, opt.yes)
+}
+
+//printDrop will print the planned drop changes of tables and their history tables
+func printDrop(conn *pg.DB, tables []string) (err error) {
+	var changes []shifter.Change
+	if changes, err = shift.PlanDrop(conn, cascade, getModels(tables)...); err == nil {
+		printChanges(changes)
+	}
+	return
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	enumCmd.AddCommand(enumUpsertCmd)
+	rootCmd.AddCommand(enumCmd)
+}
+
+var enumCmd = &cobra.Command{
+	Use:   "enum",
+	Short: "Enum operations",
+}
+
+var enumUpsertCmd = &cobra.Command{
+	Use:   "upsert [table...]",
+	Short: "Upsert Enum",
+	Long: `This will create enums of tables if not exists
+else add the new enum values.
+i.e ./shifter enum upsert test_address`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, true, printPlanOf(shifter.CreateEnumChange, shifter.AddEnumValueChange),
+			func(conn *pg.DB, tableName string) error {
+				return shift.UpsertAllEnum(conn, tableName)
+			})
+	},
+}
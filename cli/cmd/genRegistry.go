@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var registryOpt struct {
+	pkg        string
+	importPath string
+	out        string
+}
+
+func init() {
+	flags := genRegistryCmd.Flags()
+	flags.StringVar(&registryOpt.pkg, "pkg", ".", "directory of go package having table structs")
+	flags.StringVar(&registryOpt.importPath, "import", "", "import path of the package (default resolved from go.mod or GOPATH)")
+	flags.StringVarP(&registryOpt.out, "out", "o", "shifter", "directory in which main package is generated")
+	rootCmd.AddCommand(genRegistryCmd)
+}
+
+var genRegistryCmd = &cobra.Command{
+	Use:     "gen-registry",
+	Aliases: []string{"init"},
+	Short:   "Generate Model Registry",
+	Long: `This will scan the go package for structs having tableName struct{} sql tag
+and generate a main package which registers them and runs the shifter commands.
+i.e ./shifter gen-registry --pkg ./model --out ./cmd/shifter
+    go run ./cmd/shifter alter --all`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var (
+			importPath string
+			models     []string
+			src        []byte
+		)
+		if _, models, err = getTableStructs(registryOpt.pkg); err == nil {
+			if importPath = registryOpt.importPath; importPath == "" {
+				importPath, err = getImportPath(registryOpt.pkg)
+			}
+			if err == nil {
+				if src, err = getRegistrySrc(importPath, models); err == nil {
+					if err = os.MkdirAll(registryOpt.out, os.ModePerm); err == nil {
+						fPath := filepath.Join(registryOpt.out, "main.go")
+						if err = ioutil.WriteFile(fPath, src, 0644); err == nil {
+							fmt.Fprintf(stdout, "Registry created: %v (%v tables)\n", fPath, len(models))
+						}
+					}
+				}
+			}
+		}
+		return
+	},
+}
+
+//registryTmpl is the template of generated main package
+var registryTmpl = template.Must(template.New("registry").Parse(`// Code generated by shifter gen-registry. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mayur-tolexo/pg-shifter/cli/cmd"
+	{{.Alias}} "{{.ImportPath}}"
+)
+
+func main() {
+	if err := cmd.Register(
+		{{- range .Models}}
+		&{{$.Alias}}.{{.}}{},
+		{{- end}}
+	); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+`))
+
+//getRegistrySrc will return formatted source of generated main package
+//package is imported with models alias to avoid conflict with cmd import
+func getRegistrySrc(importPath string, models []string) (src []byte, err error) {
+	var buf bytes.Buffer
+	data := struct {
+		Alias      string
+		ImportPath string
+		Models     []string
+	}{"models", importPath, models}
+	if err = registryTmpl.Execute(&buf, data); err == nil {
+		src, err = format.Source(buf.Bytes())
+	}
+	return
+}
+
+//getTableStructs will return package name and exported structs
+//of the package having tableName struct{} field with sql tag
+func getTableStructs(dir string) (pkgName string, models []string, err error) {
+	var pkgs map[string]*ast.Package
+	fset := token.NewFileSet()
+	notTest := func(info os.FileInfo) bool {
+		return strings.HasSuffix(info.Name(), "_test.go") == false
+	}
+	if pkgs, err = parser.ParseDir(fset, dir, notTest, 0); err == nil {
+		for name, pkg := range pkgs {
+			if pkgName != "" {
+				err = fmt.Errorf("multiple packages found in %v", dir)
+				break
+			}
+			pkgName = name
+			for _, file := range pkg.Files {
+				models = append(models, getFileTableStructs(file)...)
+			}
+		}
+		if err == nil {
+			if pkgName == "" || pkgName == "main" {
+				err = fmt.Errorf("no importable go package found in %v", dir)
+			} else if len(models) == 0 {
+				err = fmt.Errorf("no table struct found in %v", dir)
+			}
+		}
+		sort.Strings(models)
+	}
+	return
+}
+
+//getFileTableStructs will return exported table structs of go file
+func getFileTableStructs(file *ast.File) (models []string) {
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				tSpec := spec.(*ast.TypeSpec)
+				if st, ok := tSpec.Type.(*ast.StructType); ok &&
+					tSpec.Name.IsExported() && isTableStruct(st) {
+					models = append(models, tSpec.Name.Name)
+				}
+			}
+		}
+	}
+	return
+}
+
+//isTableStruct will check struct has tableName struct{} field with sql tag
+func isTableStruct(st *ast.StructType) (flag bool) {
+	for _, field := range st.Fields.List {
+		fType, ok := field.Type.(*ast.StructType)
+		if ok && field.Tag != nil && len(fType.Fields.List) == 0 {
+			for _, name := range field.Names {
+				if name.Name == "tableName" {
+					tag, _ := strconv.Unquote(field.Tag.Value)
+					_, flag = reflect.StructTag(tag).Lookup("sql")
+				}
+			}
+		}
+	}
+	return
+}
+
+//getImportPath will return import path of package directory
+//resolved from nearest go.mod or GOPATH
+func getImportPath(dir string) (importPath string, err error) {
+	var absDir string
+	if absDir, err = filepath.Abs(dir); err == nil {
+		for cur := absDir; ; cur = filepath.Dir(cur) {
+			if module := getModuleName(filepath.Join(cur, "go.mod")); module != "" {
+				rel, _ := filepath.Rel(cur, absDir)
+				importPath = filepath.ToSlash(filepath.Join(module, rel))
+				break
+			}
+			if cur == filepath.Dir(cur) {
+				break
+			}
+		}
+		if importPath == "" {
+			for _, gopath := range filepath.SplitList(os.Getenv("GOPATH")) {
+				src := filepath.Join(gopath, "src") + string(filepath.Separator)
+				if strings.HasPrefix(absDir, src) {
+					importPath = filepath.ToSlash(strings.TrimPrefix(absDir, src))
+					break
+				}
+			}
+		}
+		if importPath == "" {
+			err = fmt.Errorf("import path of %v not found, use --import", dir)
+		}
+	}
+	return
+}
+
+//getModuleName will return module name from go.mod file
+func getModuleName(modFile string) (module string) {
+	if f, err := os.Open(modFile); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "module ") {
+				module = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+				break
+			}
+		}
+	}
+	return
+}
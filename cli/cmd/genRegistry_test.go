@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTableStructs(t *testing.T) {
+	assert := assert.New(t)
+	pkgName, models, err := getTableStructs("../../db")
+	assert.NoError(err)
+	assert.Equal("db", pkgName)
+	assert.Equal([]string{"TestAddress", "TestAdminUser", "TestUser"}, models)
+
+	src, err := getRegistrySrc("github.com/mayur-tolexo/pg-shifter/db", models)
+	assert.NoError(err)
+	assert.Contains(string(src), `models "github.com/mayur-tolexo/pg-shifter/db"`)
+	assert.Contains(string(src), "&models.TestAdminUser{},")
+
+	_, _, err = getTableStructs("..")
+	assert.Error(err)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+	"github.com/spf13/cobra"
+)
+
+var structPath string
+
+func init() {
+	genStructCmd.Flags().StringVarP(&structPath, "path", "p", "", "directory in which struct files are created (default pwd/log/)")
+	rootCmd.AddCommand(genStructCmd)
+}
+
+var genStructCmd = &cobra.Command{
+	Use:   "gen-struct [table...]",
+	Short: "Create Table Struct",
+	Long: `This will create golang struct of tables from database.
+Tables need not to be registered.
+i.e ./shifter gen-struct test_address --path ./model`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, false, printGenStruct, func(conn *pg.DB, tableName string) error {
+			return shift.CreateStruct(conn, tableName, structPath)
+		})
+	},
+}
+
+//printGenStruct will print tables whose struct will be created
+func printGenStruct(conn *pg.DB, tables []string) (err error) {
+	for _, tableName := range tables {
+		fmt.Fprintln(stdout, "-- create struct", tableName)
+	}
+	return
+}
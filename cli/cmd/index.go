@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	indexCmd.AddCommand(indexSyncCmd)
+	rootCmd.AddCommand(indexCmd)
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Index operations",
+}
+
+var indexSyncCmd = &cobra.Command{
+	Use:   "sync [table...]",
+	Short: "Sync Index",
+	Long: `This will create/recreate/drop index of tables as defined in struct Index() method.
+i.e ./shifter index sync test_address --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, true, printPlanOf(shifter.CreateIndexChange, shifter.DropIndexChange),
+			func(conn *pg.DB, tableName string) error {
+				return shift.UpsertAllIndex(conn, tableName, opt.yes)
+			})
+	},
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+//migrationDir is the directory of migration files
+//migrationFormat is the layout of migration files
+var migrationDir, migrationFormat string
+
+func init() {
+	migrationCmd.Flags().StringVar(&migrationDir, "dir", "./migrations", "directory of migration files")
+	migrationCmd.Flags().StringVar(&migrationFormat, "format", string(shifter.MigrateFormat),
+		"migration file format: migrate (up/down files) or goose")
+	rootCmd.AddCommand(migrationCmd)
+}
+
+var migrationCmd = &cobra.Command{
+	Use:   "migration <name> [table...]",
+	Short: "Write Migration Files",
+	Long: `This will write the planned changes of tables as versioned
+up and down sql migration files without changing anything in database.
+Files are compatible with golang-migrate (default) and goose.
+i.e ./shifter migration add_user_email --all
+./shifter migration add_user_email --all --dir ./migrations --format goose`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if migrationFormat != string(shifter.MigrateFormat) &&
+			migrationFormat != string(shifter.GooseFormat) {
+			return fmt.Errorf("invalid migration format %v", migrationFormat)
+		}
+		opt.dryRun = true
+		name := args[0]
+		return runTables(args[1:], true, func(conn *pg.DB, tables []string) error {
+			return writeMigration(conn, tables, name)
+		}, func(conn *pg.DB, tableName string) error {
+			return nil
+		})
+	},
+}
+
+//writeMigration will write the migration files of tables
+func writeMigration(conn *pg.DB, tables []string, name string) (err error) {
+	var files []string
+	shift.SetMigrationFormat(shifter.MigrationFormat(migrationFormat))
+	if files, err = shift.WriteMigration(conn, migrationDir, name, getModels(tables)...); err == nil {
+		if len(files) == 0 {
+			fmt.Fprintln(stdout, "-- no change")
+		}
+		for _, file := range files {
+			fmt.Fprintln(stdout, file)
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+//planSnapshot is the snapshot file used to plan without database
+var planSnapshot string
+
+func init() {
+	planCmd.Flags().StringVar(&planSnapshot, "snapshot", "",
+		"json/yaml snapshot file to plan without database connection")
+	rootCmd.AddCommand(planCmd)
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan [table...]",
+	Short: "Plan Changes",
+	Long: `This will print the sql which create/alter will execute
+without changing anything in database.
+With --snapshot, existing schema is read from the snapshot file
+dumped by snapshot command instead of database.
+i.e ./shifter plan --all
+./shifter plan --all --snapshot schema.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if planSnapshot != "" {
+			return printOfflinePlan(args)
+		}
+		opt.dryRun = true
+		return runTables(args, true, printPlan, func(conn *pg.DB, tableName string) error {
+			return nil
+		})
+	},
+}
+
+//printOfflinePlan will print the planned changes of tables using the snapshot file
+func printOfflinePlan(args []string) (err error) {
+	var (
+		tables  []string
+		snap    shifter.Snapshot
+		changes []shifter.Change
+	)
+	if tables, err = getTables(args, true); err == nil {
+		if snap, err = shifter.LoadSnapshot(planSnapshot); err == nil {
+			if opt.schema != "" {
+				shift.Schema(opt.schema)
+			}
+			if changes, err = shift.PlanOffline(snap, getModels(tables)...); err == nil {
+				printChanges(changes)
+			}
+		}
+	}
+	return
+}
@@ -1,17 +1,185 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
 	"github.com/spf13/cobra"
 )
 
+//options are the global flags of all commands
+type options struct {
+	config string
+	yes    bool
+	dryRun bool
+	all    bool
+	tables []string
+	schema string
+	//lock and statement timeout of transaction and retry on lock timeout
+	lockTimeout time.Duration
+	stmtTimeout time.Duration
+	retry       int
+	backoff     time.Duration
+	//advisory lock key and wait timeout
+	lockKey  string
+	lockWait time.Duration
+}
+
 var (
 	rootCmd = &cobra.Command{
-		Use:   "PG-Shifter",
-		Short: "This library make you enable migrate postgresql schema from golang struct",
+		Use:          "shifter",
+		Short:        "This library make you enable migrate postgresql schema from golang struct",
+		SilenceUsage: true,
 	}
+	shift = shifter.NewShifter()
+	opt   options
+	//stdout is the writer of command output
+	stdout io.Writer = os.Stdout
 )
 
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVarP(&opt.config, "config", "c", "dev.yaml", "yaml file having database connection")
+	flags.BoolVarP(&opt.yes, "yes", "y", false, "execute without prompt")
+	flags.BoolVar(&opt.dryRun, "dry-run", false, "print the sql without executing")
+	flags.BoolVar(&opt.all, "all", false, "all registered tables")
+	flags.StringSliceVarP(&opt.tables, "tables", "t", nil, "comma separated table names")
+	flags.StringVarP(&opt.schema, "schema", "s", "", "postgresql schema of the tables (default search path)")
+	flags.DurationVar(&opt.lockTimeout, "lock-timeout", 0, "lock_timeout of transaction i.e. 5s (default no timeout)")
+	flags.DurationVar(&opt.stmtTimeout, "statement-timeout", 0, "statement_timeout of transaction (default no timeout)")
+	flags.IntVar(&opt.retry, "retry", 1, "attempts of sql failed with lock timeout")
+	flags.DurationVar(&opt.backoff, "retry-backoff", time.Second, "wait before retry, doubled after every retry")
+	flags.StringVar(&opt.lockKey, "lock-key", "", "advisory lock key so that only one process migrates at a time")
+	flags.DurationVar(&opt.lockWait, "lock-wait", 0, "wait for advisory lock (default no timeout)")
+}
+
+// Register will register table struct pointers in shifter used by the commands.
+// Commands can only migrate the registered tables.
+func Register(models ...interface{}) error {
+	return shift.SetTableModels(models)
+}
+
+//Shifter will return the shifter used by the commands
+func Shifter() *shifter.Shifter {
+	return shift
+}
+
 // Execute executes the root command.
 func Execute() error {
 	return rootCmd.Execute()
 }
+
+//getTables will return table names passed as args, --tables or --all
+//if registered is true then all tables must be registered in shifter
+func getTables(args []string, registered bool) (tables []string, err error) {
+	if opt.all {
+		tables = shift.TableNames()
+	} else {
+		tables = append(tables, opt.tables...)
+		tables = append(tables, args...)
+	}
+	if len(tables) == 0 {
+		err = fmt.Errorf("no table given, pass table names or use --tables/--all")
+	} else if registered {
+		regTables := shift.TableNames()
+		for _, tableName := range tables {
+			if idx := sort.SearchStrings(regTables, tableName); idx == len(regTables) ||
+				regTables[idx] != tableName {
+				err = fmt.Errorf("table %v is not registered", tableName)
+				break
+			}
+		}
+	}
+	return
+}
+
+//printPlan will print the planned changes of tables
+func printPlan(conn *pg.DB, tables []string) (err error) {
+	return printPlanOf()(conn, tables)
+}
+
+//printPlanOf will return dry run func which prints the planned changes of given kinds
+//so that command prints only the changes it executes. All changes are printed if no kind is given
+func printPlanOf(kinds ...shifter.ChangeKind) func(conn *pg.DB, tables []string) error {
+	return func(conn *pg.DB, tables []string) (err error) {
+		var changes []shifter.Change
+		if changes, err = shift.Plan(conn, getModels(tables)...); err == nil {
+			printChanges(filterChanges(changes, kinds...))
+		}
+		return
+	}
+}
+
+//filterChanges will return the changes of given kinds
+func filterChanges(changes []shifter.Change, kinds ...shifter.ChangeKind) (
+	filtered []shifter.Change) {
+
+	if len(kinds) == 0 {
+		return changes
+	}
+	for _, c := range changes {
+		for _, kind := range kinds {
+			if c.Kind == kind {
+				filtered = append(filtered, c)
+				break
+			}
+		}
+	}
+	return
+}
+
+//getModels will return table names as models of shifter
+func getModels(tables []string) (models []interface{}) {
+	models = make([]interface{}, 0, len(tables))
+	for _, tableName := range tables {
+		models = append(models, tableName)
+	}
+	return
+}
+
+//printChanges will print the changes with kind, table and safety level
+func printChanges(changes []shifter.Change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "-- no change")
+	}
+	for _, c := range changes {
+		name := c.Table
+		if c.Column != "" {
+			name += "." + c.Column
+		}
+		fmt.Fprintf(stdout, "-- %v %v (%v)\n%v\n", c.Kind, name, c.Safety, strings.TrimSpace(c.SQL))
+	}
+}
+
+//runTables will connect to database and execute fn on each table
+//in dry run the dryRun func is executed on all tables instead
+func runTables(args []string, registered bool,
+	dryRun func(conn *pg.DB, tables []string) error,
+	fn func(conn *pg.DB, tableName string) error) (err error) {
+
+	var (
+		conn   *pg.DB
+		tables []string
+	)
+	if tables, err = getTables(args, registered); err == nil {
+		if conn, err = connect(); err == nil {
+			defer conn.Close()
+			if opt.dryRun {
+				err = dryRun(conn, tables)
+			} else {
+				for _, tableName := range tables {
+					if err = fn(conn, tableName); err != nil {
+						break
+					}
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags(t *testing.T) {
+	assert := assert.New(t)
+	old := opt
+	defer func() { opt = old }()
+
+	assert.Equal("dev.yaml", opt.config)
+	assert.Equal(1, opt.retry)
+	err := rootCmd.PersistentFlags().Parse([]string{"-y", "--dry-run", "-s", "tenant_1",
+		"-t", "test_user,test_address", "--lock-timeout", "5s", "--retry", "3"})
+	assert.NoError(err)
+	assert.True(opt.yes)
+	assert.True(opt.dryRun)
+	assert.Equal("tenant_1", opt.schema)
+	assert.Equal([]string{"test_user", "test_address"}, opt.tables)
+	assert.Equal(5*time.Second, opt.lockTimeout)
+	assert.Equal(3, opt.retry)
+}
+
+func TestGetTables(t *testing.T) {
+	assert := assert.New(t)
+	old := opt
+	defer func() { opt = old }()
+	assert.NoError(Register(&db.TestAddress{}, &db.TestUser{}))
+
+	opt.tables = []string{"test_user"}
+	tables, err := getTables([]string{"test_address"}, true)
+	assert.NoError(err)
+	assert.Equal([]string{"test_user", "test_address"}, tables)
+
+	_, err = getTables([]string{"test_invoice"}, true)
+	assert.EqualError(err, "table test_invoice is not registered")
+	tables, err = getTables([]string{"test_invoice"}, false)
+	assert.NoError(err)
+	assert.Equal([]string{"test_user", "test_invoice"}, tables)
+
+	opt.tables, opt.all = nil, true
+	tables, err = getTables(nil, true)
+	assert.NoError(err)
+	assert.Equal(shift.TableNames(), tables)
+
+	opt.all = false
+	_, err = getTables(nil, true)
+	assert.Error(err)
+}
+
+func TestFilterChanges(t *testing.T) {
+	assert := assert.New(t)
+	changes := []shifter.Change{
+		{Kind: shifter.AddColumnChange, Table: "test_user"},
+		{Kind: shifter.CreateIndexChange, Table: "test_user"},
+		{Kind: shifter.DropIndexChange, Table: "test_user"},
+	}
+	assert.Equal(changes, filterChanges(changes))
+	assert.Equal(changes[1:], filterChanges(changes, shifter.CreateIndexChange, shifter.DropIndexChange))
+	assert.Empty(filterChanges(changes, shifter.AddUniqueKeyChange))
+	//create doesn't print alter changes of existing table
+	assert.Equal(changes[1:2], filterChanges(changes, createKinds...))
+}
+
+func TestDryRunOutput(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() { stdout = os.Stdout }()
+
+	printChanges(nil)
+	assert.Equal("-- no change\n", buf.String())
+
+	buf.Reset()
+	printChanges([]shifter.Change{{Kind: shifter.CreateIndexChange, Table: "test_user", Column: "name",
+		Safety: shifter.SafeLevel, SQL: "CREATE INDEX idx_name ON test_user (name);\n"}})
+	assert.Equal("-- create index test_user.name (safe)\nCREATE INDEX idx_name ON test_user (name);\n",
+		buf.String())
+
+	buf.Reset()
+	assert.NoError(printTrigger(nil, []string{"test_user"}))
+	assert.NoError(printGenStruct(nil, []string{"test_user"}))
+	assert.Equal("-- create trigger test_user\n-- create struct test_user\n", buf.String())
+}
+
+func TestOfflinePlanCmd(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() { stdout = os.Stdout }()
+
+	dir, err := ioutil.TempDir("", "shifter")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "schema.yaml")
+	assert.NoError(shifter.Snapshot{}.Write(path))
+
+	assert.NoError(Register(&db.TestAddress{}, &db.TestUser{}))
+	rootCmd.SetArgs([]string{"plan", "--snapshot", path, "test_address"})
+	assert.NoError(rootCmd.Execute())
+	planSnapshot = ""
+	assert.Contains(buf.String(), "-- create table test_address (safe)\n")
+	assert.Contains(buf.String(), "CREATE TABLE IF NOT EXISTS test_address")
+}
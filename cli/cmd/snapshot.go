@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+//snapshotOut is the file in which snapshot is written
+var snapshotOut string
+
+func init() {
+	snapshotCmd.Flags().StringVarP(&snapshotOut, "out", "o", "schema.yaml",
+		"snapshot file, written as json if extension is .json else yaml")
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [table...]",
+	Short: "Dump Schema Snapshot",
+	Long: `This will dump the database schema of tables, their history tables
+and enums in json/yaml file which can be used by plan --snapshot
+to plan the changes without database.
+i.e ./shifter snapshot --all --out schema.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opt.dryRun = true
+		return runTables(args, true, writeSnapshot, func(conn *pg.DB, tableName string) error {
+			return nil
+		})
+	},
+}
+
+//writeSnapshot will write the snapshot of tables in snapshot file
+func writeSnapshot(conn *pg.DB, tables []string) (err error) {
+	var snap shifter.Snapshot
+	if snap, err = shift.Snapshot(conn, getModels(tables)...); err == nil {
+		if err = snap.Write(snapshotOut); err == nil {
+			fmt.Fprintf(stdout, "Snapshot of %v tables written in %v\n", len(snap.Tables), snapshotOut)
+		}
+	}
+	return
+}
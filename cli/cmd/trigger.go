@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(triggerCmd)
+}
+
+var triggerCmd = &cobra.Command{
+	Use:   "trigger [table...]",
+	Short: "Create Trigger",
+	Long: `This will create triggers mentioned on struct table name tag.
+i.e ./shifter trigger test_address`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, true, printTrigger, func(conn *pg.DB, tableName string) error {
+			return shift.CreateTrigger(conn, tableName)
+		})
+	},
+}
+
+//printTrigger will print tables whose triggers will be created
+func printTrigger(conn *pg.DB, tables []string) (err error) {
+	for _, tableName := range tables {
+		fmt.Fprintln(stdout, "-- create trigger", tableName)
+	}
+	return
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/go-pg/pg"
+	shifter "github.com/mayur-tolexo/pg-shifter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	ukCmd.AddCommand(ukSyncCmd)
+	rootCmd.AddCommand(ukCmd)
+}
+
+var ukCmd = &cobra.Command{
+	Use:   "uk",
+	Short: "Composite unique key operations",
+}
+
+var ukSyncCmd = &cobra.Command{
+	Use:   "sync [table...]",
+	Short: "Sync Unique Key",
+	Long: `This will create/recreate/drop composite unique key of tables
+as defined in struct UniqueKey() method.
+i.e ./shifter uk sync test_address --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTables(args, true, printPlanOf(shifter.AddUniqueKeyChange, shifter.DropUniqueKeyChange),
+			func(conn *pg.DB, tableName string) error {
+				return shift.UpsertAllUniqueKey(conn, tableName, opt.yes)
+			})
+	},
+}
@@ -1,7 +1,13 @@
 package main
 
-import "github.com/mayur-tolexo/pg-shifter/cli/cmd"
+import (
+	"os"
+
+	"github.com/mayur-tolexo/pg-shifter/cli/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,226 @@
+package shifter
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//getCompositeKeyFromMethod will return composite primary key and foreign keys
+//of struct defined in PrimaryKey() and ForeignKeys() methods by constraint name.
+//Single column keys are defined in sql tag so those are not considered here
+func (s *Shifter) getCompositeKeyFromMethod(tName string) (key map[string]model.KeySchema) {
+	dbModel := s.table[tName]
+	refObj := reflect.ValueOf(dbModel)
+	key = make(map[string]model.KeySchema)
+
+	if m := refObj.MethodByName("PrimaryKey"); m.IsValid() {
+		out := m.Call([]reflect.Value{})
+		if len(out) > 0 && out[0].Kind() == reflect.Slice {
+			if pk, ok := out[0].Interface().([]string); ok && len(pk) > 1 {
+				pkName := fmt.Sprintf("%v_%v", util.GetBareName(tName), primaryKeySuffix)
+				key[pkName] = model.KeySchema{
+					TableName:         tName,
+					ConstraintName:    pkName,
+					ConstraintType:    primaryKey,
+					Columns:           getTrimmedColumns(strings.Join(pk, ",")),
+					IsDeferrable:      no,
+					InitiallyDeferred: no,
+				}
+			}
+		}
+	}
+
+	if m := refObj.MethodByName("ForeignKeys"); m.IsValid() {
+		out := m.Call([]reflect.Value{})
+		if len(out) > 0 && out[0].Kind() == reflect.Slice {
+			if fks, ok := out[0].Interface().([]model.ForeignKey); ok {
+				for _, fk := range fks {
+					if len(fk.Columns) > 1 {
+						fkSchema := getFKSchema(tName, fk)
+						key[fkSchema.ConstraintName] = fkSchema
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+//getFKSchema will return key schema of struct foreign key
+func getFKSchema(tName string, fk model.ForeignKey) (key model.KeySchema) {
+	columns := getTrimmedColumns(strings.Join(fk.Columns, ","))
+	fName := strings.Replace(columns, ",", "_", -1)
+	fkName := fmt.Sprintf("%v_%v_%v", util.GetBareName(tName), fName, foreignKeySuffix)
+	key = model.KeySchema{
+		TableName:         tName,
+		ConstraintName:    util.GetUniqueStrByLen(fkName, 64),
+		ConstraintType:    foreignKey,
+		Columns:           columns,
+		ForeignTableName:  fk.RefTable,
+		ForeignColumns:    getTrimmedColumns(strings.Join(fk.RefColumns, ",")),
+		UpdateType:        getKeyActionFlag(fk.OnUpdate),
+		DeleteType:        getKeyActionFlag(fk.OnDelete),
+		IsDeferrable:      no,
+		InitiallyDeferred: no,
+	}
+	if fk.Deferrable {
+		key.IsDeferrable = yes
+		if fk.InitiallyDeferred {
+			key.InitiallyDeferred = yes
+		}
+	}
+	return
+}
+
+//getKeyActionFlag will return constraint flag of on delete/update action
+//i.e. set null to n
+func getKeyActionFlag(action string) (flag string) {
+	action = strings.Replace(strings.ToLower(action), " ", "", -1)
+	flag = getConstraintFlag(action)
+	return
+}
+
+//isKeyEqual will check table and struct composite keys are same
+func isKeyEqual(tKey, sKey model.KeySchema) (equal bool) {
+	equal = tKey.ConstraintType == sKey.ConstraintType &&
+		getTrimmedColumns(tKey.Columns) == sKey.Columns
+	if equal && sKey.ConstraintType == foreignKey {
+		equal = tKey.ForeignTableName == sKey.ForeignTableName &&
+			getTrimmedColumns(tKey.ForeignColumns) == sKey.ForeignColumns &&
+			tKey.UpdateType == sKey.UpdateType &&
+			tKey.DeleteType == sKey.DeleteType &&
+			tKey.IsDeferrable == sKey.IsDeferrable &&
+			tKey.InitiallyDeferred == sKey.InitiallyDeferred
+	}
+	return
+}
+
+//dropCompositeKey will drop composite primary/foreign keys which are removed or
+//modified in struct. Returned struct keys are the keys need to be added
+func (s *Shifter) dropCompositeKey(tx Tx, tName string, skipPrompt bool) (
+	tKey []model.KeySchema, sKey map[string]model.KeySchema, isAlter bool, err error) {
+
+	defer func() { s.logMode(false) }()
+	sKey = s.getCompositeKeyFromMethod(tName)
+	if tKey, err = s.catalog(tx).CompositeKey(tName); err == nil {
+		s.logMode(s.verbose)
+		//foreign keys are dropped before primary key
+		dropKey := append([]model.KeySchema{}, tKey...)
+		for _, curTableKey := range getSortedKeySchema(dropKey, true) {
+			var curAlter bool
+			if name, exists := getKeyByDefinition(sKey, curTableKey); exists {
+				//key is not modified
+				delete(sKey, name)
+			} else {
+				//key is removed or modified in struct
+				//so dropping it and modified key will be created again
+				sql := getDropConstraintSQL(tName, curTableKey.ConstraintName)
+				c := newChange(DropCompositeKeyChange, tName, curTableKey.Columns, sql)
+				c.DownSQL = getAddCompositeKeySQL(curTableKey)
+				if curAlter, err = s.execByChoice(tx, c, skipPrompt); err != nil {
+					break
+				}
+			}
+			isAlter = isAlter || curAlter
+		}
+	}
+	return
+}
+
+//addCompositeKey will add composite primary/foreign keys which are not in table
+func (s *Shifter) addCompositeKey(tx Tx, tName string, sKey map[string]model.KeySchema,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	defer func() { s.logMode(false) }()
+	s.logMode(s.verbose)
+
+	var keys []model.KeySchema
+	for _, key := range sKey {
+		keys = append(keys, key)
+	}
+	//primary key is added before foreign keys
+	for _, key := range getSortedKeySchema(keys, false) {
+		var curAlter bool
+		c := newChange(AddCompositeKeyChange, tName, key.Columns, getAddCompositeKeySQL(key))
+		c.DownSQL = getDropConstraintSQL(tName, key.ConstraintName)
+		if curAlter, err = s.execByChoice(tx, c, skipPrompt); err != nil {
+			break
+		}
+		isAlter = isAlter || curAlter
+	}
+	return
+}
+
+//getKeyByDefinition will return struct key name having same definition as table key
+func getKeyByDefinition(sKey map[string]model.KeySchema, tKey model.KeySchema) (
+	name string, exists bool) {
+	for curName, key := range sKey {
+		if isKeyEqual(tKey, key) {
+			name, exists = curName, true
+			break
+		}
+	}
+	return
+}
+
+//getSortedKeySchema will return keys sorted by type and name
+//if fkFirst then foreign keys come before primary key
+func getSortedKeySchema(keys []model.KeySchema, fkFirst bool) []model.KeySchema {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ConstraintType != keys[j].ConstraintType {
+			return (keys[i].ConstraintType == foreignKey) == fkFirst
+		}
+		return keys[i].ConstraintName < keys[j].ConstraintName
+	})
+	return keys
+}
+
+//getAddCompositeKeySQL will return add composite primary/foreign key sql
+func getAddCompositeKeySQL(key model.KeySchema) (sql string) {
+	if key.ConstraintType == foreignKey {
+		sql = fmt.Sprintf(" REFERENCES %v(%v) ON DELETE %v ON UPDATE %v",
+			key.ForeignTableName, key.ForeignColumns,
+			getConstraintTagByFlag(key.DeleteType), getConstraintTagByFlag(key.UpdateType))
+		sql += getDefferSQL(model.ColSchema{IsDeferrable: key.IsDeferrable,
+			InitiallyDeferred: key.InitiallyDeferred})
+	}
+	sql = fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v %v (%v)%v;\n",
+		key.TableName, key.ConstraintName, key.ConstraintType, key.Columns, sql)
+	return
+}
+
+//getDBCompositeKey : Get composite primary and foreign keys of table from database
+func getDBCompositeKey(tx Tx, tableName string) (key []model.KeySchema, err error) {
+	query := `
+	SELECT pgc.conname,
+	CASE pgc.contype WHEN 'p' THEN 'PRIMARY KEY' ELSE 'FOREIGN KEY' END AS constraint_type,
+	(SELECT string_agg(a.attname, ',' ORDER BY k.n)
+		FROM unnest(pgc.conkey) WITH ORDINALITY AS k(attnum, n)
+		JOIN pg_attribute AS a ON a.attrelid = pgc.conrelid AND a.attnum = k.attnum
+	) AS col,
+	CASE pgc.contype WHEN 'f' THEN pgc.confrelid::regclass::text ELSE '' END AS foreign_table_name,
+	CASE pgc.contype WHEN 'f' THEN (SELECT string_agg(a.attname, ',' ORDER BY k.n)
+		FROM unnest(pgc.confkey) WITH ORDINALITY AS k(attnum, n)
+		JOIN pg_attribute AS a ON a.attrelid = pgc.confrelid AND a.attnum = k.attnum
+	) ELSE '' END AS foreign_col,
+	pgc.confupdtype, pgc.confdeltype,
+	CASE WHEN pgc.condeferrable THEN 'YES' ELSE 'NO' END AS is_deferrable,
+	CASE WHEN pgc.condeferred THEN 'YES' ELSE 'NO' END AS initially_deferred
+	FROM pg_constraint AS pgc
+	WHERE pgc.conrelid = ?::regclass::oid AND pgc.contype IN ('p','f')
+	AND array_length(pgc.conkey,1) > 1
+	ORDER BY pgc.conname;`
+	if err = tx.Query(&key, query, tableName); err != nil {
+		err = getWrapError(tableName, "composite key", query, err)
+	} else {
+		for i := range key {
+			key[i].TableName = tableName
+		}
+	}
+	return
+}
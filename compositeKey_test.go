@@ -0,0 +1,102 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type localShipment struct {
+	tableName struct{} `sql:"local_shipment"`
+	OrderID   int      `sql:"order_id,type:int NOT NULL"`
+	LineNo    int      `sql:"line_no,type:int NOT NULL"`
+}
+
+//PrimaryKey of the table
+func (localShipment) PrimaryKey() []string {
+	return []string{"order_id", "line_no"}
+}
+
+type localShipmentItem struct {
+	tableName struct{} `sql:"local_shipment_item"`
+	ItemID    int      `sql:"item_id,type:serial PRIMARY KEY"`
+	OrderID   int      `sql:"order_id,type:int NOT NULL"`
+	LineNo    int      `sql:"line_no,type:int NOT NULL"`
+}
+
+//ForeignKeys of the table
+func (localShipmentItem) ForeignKeys() []model.ForeignKey {
+	return []model.ForeignKey{
+		{
+			Columns:           []string{"order_id", "line_no"},
+			RefTable:          "local_shipment",
+			RefColumns:        []string{"order_id", "line_no"},
+			OnDelete:          "cascade",
+			OnUpdate:          "Set Null",
+			Deferrable:        true,
+			InitiallyDeferred: true,
+		},
+		{
+			Columns:    []string{"order_id"},
+			RefTable:   "local_shipment",
+			RefColumns: []string{"order_id"},
+		},
+	}
+}
+
+func TestCompositeKeyFromMethod(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&localShipmentItem{}, &localShipment{})
+
+	pk := s.getCompositeKeyFromMethod("local_shipment")
+	if assert.Contains(pk, "local_shipment_pkey") {
+		assert.Equal("ALTER TABLE local_shipment ADD CONSTRAINT local_shipment_pkey PRIMARY KEY (order_id,line_no);\n",
+			getAddCompositeKeySQL(pk["local_shipment_pkey"]))
+	}
+
+	fk := s.getCompositeKeyFromMethod("local_shipment_item")
+	if assert.Len(fk, 1) && assert.Contains(fk, "local_shipment_item_order_id_line_no_fkey") {
+		key := fk["local_shipment_item_order_id_line_no_fkey"]
+		assert.Equal("ALTER TABLE local_shipment_item ADD CONSTRAINT local_shipment_item_order_id_line_no_fkey "+
+			"FOREIGN KEY (order_id,line_no) REFERENCES local_shipment(order_id,line_no) "+
+			"ON DELETE CASCADE ON UPDATE SET NULL DEFERRABLE INITIALLY DEFERRED;\n",
+			getAddCompositeKeySQL(key))
+
+		tKey := key
+		tKey.ConstraintName = "old_fkey"
+		tKey.Columns = "order_id, line_no"
+		assert.True(isKeyEqual(tKey, key))
+		tKey.DeleteType = "a"
+		assert.False(isKeyEqual(tKey, key))
+	}
+
+	order, _ := s.DependencyOrder()
+	assert.Equal([]string{"local_shipment", "local_shipment_item"}, order)
+	assert.Equal([]string{"", "", "", "local_shipment"}, s.getRefTables("local_shipment_item"))
+}
+
+func TestCompositeKeyStructLog(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	schema := map[string]model.ColSchema{
+		"order_id": {TableName: "local_shipment_item", ColumnName: "order_id", DataType: "integer",
+			IsNullable: "NO", Position: 1},
+	}
+	key := []model.KeySchema{
+		{TableName: "local_shipment_item", ConstraintName: "local_shipment_item_pkey",
+			ConstraintType: primaryKey, Columns: "order_id,line_no"},
+		{TableName: "local_shipment_item", ConstraintName: "local_shipment_item_order_id_line_no_fkey",
+			ConstraintType: foreignKey, Columns: "order_id,line_no", ForeignTableName: "local_shipment",
+			ForeignColumns: "order_id,line_no", DeleteType: "c", UpdateType: "a",
+			IsDeferrable: yes, InitiallyDeferred: no},
+	}
+	_, fData, err := s.getTableStructSchema(schema, nil, key, nil, false)
+	assert.NoError(err)
+	str := string(fData)
+	assert.Contains(str, `return []string{"order_id", "line_no"}`)
+	assert.Contains(str, `"github.com/mayur-tolexo/pg-shifter/model"`)
+	assert.Contains(str, `RefTable:          "local_shipment",`)
+	assert.Contains(str, `OnDelete:          "cascade",`)
+	assert.Contains(str, `Deferrable:        true,`)
+}
@@ -0,0 +1,142 @@
+package shifter
+
+import (
+	"fmt"
+	"sort"
+)
+
+//Concurrent will enable concurrent index build so that table writes are not blocked.
+//Index creation and drop of existing table are executed using CONCURRENTLY
+//after the transaction is committed. Composite unique key is added by
+//CREATE UNIQUE INDEX CONCURRENTLY and then ADD CONSTRAINT ... USING INDEX.
+//Invalid indexes left by failed concurrent build are dropped and built again
+func (s *Shifter) Concurrent(enable bool) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.concurrent = enable
+	return s
+}
+
+//isConcurrent will check index of the table need to be built concurrently
+//index of table created in this run is built in the transaction
+func (s *Shifter) isConcurrent(tableName string) bool {
+	_, created := s.run.newTables[tableName]
+	return s.concurrent && created == false
+}
+
+//getConcurrentIndexSQL will return create index concurrently sql
+//table is schema qualified as search path is not set outside transaction
+func (s *Shifter) getConcurrentIndexSQL(idxName, tableName, indexDS, column string) string {
+	return fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %v ON %v USING %v (%v);\n",
+		idxName, s.getQualifiedTableName(tableName), getIndexType(indexDS), column)
+}
+
+//getConcurrentDropIndexSQL will return drop index concurrently sql
+func (s *Shifter) getConcurrentDropIndexSQL(tableName, idxName string) string {
+	if schema := s.getTableSchemaName(tableName); schema != "" {
+		idxName = schema + "." + idxName
+	}
+	return fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %v;\n", idxName)
+}
+
+//addCompositeUKConcurrently will add composite unique keys by building unique index
+//concurrently and then adding the constraint using the index
+func (s *Shifter) addCompositeUKConcurrently(tx Tx, tName string,
+	sUK map[string]string, skipPrompt bool) (isAlter bool, err error) {
+
+	var ukNames []string
+	for ukName, ukFields := range sUK {
+		//only for more than one fields
+		if isCompositeUk(ukFields) {
+			ukNames = append(ukNames, ukName)
+		}
+	}
+	sort.Strings(ukNames)
+	for _, ukName := range ukNames {
+		var curAlter bool
+		sql := fmt.Sprintf("CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS %v ON %v (%v);\n",
+			ukName, s.getQualifiedTableName(tName), sUK[ukName])
+		c := newChange(AddUniqueKeyChange, tName, sUK[ukName], sql)
+		c.DownSQL = getDropIndexSQL(tName, ukName)
+		c.NoTx = true
+		if curAlter, err = s.execByChoice(tx, c, skipPrompt); err != nil {
+			break
+		} else if curAlter {
+			//constraint is added using the index once it is built
+			sql = fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v UNIQUE USING INDEX %v;\n",
+				tName, ukName, ukName)
+			uc := newChange(AddUniqueKeyChange, tName, sUK[ukName], sql)
+			uc.DownSQL = getDropConstraintSQL(tName, ukName)
+			s.run.online = append(s.run.online, uc)
+		}
+		isAlter = isAlter || curAlter
+	}
+	return
+}
+
+//dropInvalidIndex will drop invalid indexes of the table left by failed concurrent build
+//so that those are built again
+func (s *Shifter) dropInvalidIndex(tx Tx, tableName string, skipPrompt bool) (
+	isAlter bool, err error) {
+
+	var idxName []string
+	if idxName, err = s.catalog(tx).InvalidIndex(tableName); err == nil {
+		for _, curIdx := range idxName {
+			var curAlter bool
+			c := newChange(DropIndexChange, tableName, "", getDropIndexSQL(tableName, curIdx))
+			if s.isConcurrent(tableName) {
+				c.SQL, c.NoTx = s.getConcurrentDropIndexSQL(tableName, curIdx), true
+			}
+			if curAlter, err = s.execByChoice(tx, c, skipPrompt); err != nil {
+				break
+			}
+			isAlter = isAlter || curAlter
+		}
+	}
+	return
+}
+
+//getDBInvalidIndex : Get invalid index of table from database
+func getDBInvalidIndex(tx Tx, tableName string) (idxName []string, err error) {
+	query := `SELECT i.relname FROM pg_index AS ix
+	JOIN pg_class AS i ON i.oid = ix.indexrelid
+	WHERE ix.indrelid = ?::regclass::oid AND ix.indisvalid = false
+	ORDER BY i.relname;`
+	if err = tx.Query(&idxName, query, tableName); err != nil {
+		err = getWrapError(tableName, "invalid index", query, err)
+	}
+	return
+}
+
+//execNoTx will execute the step without transaction
+//lock and statement timeout are set in a dedicated session as SET LOCAL has no effect
+//outside transaction. Session is not used if executor is not a Sessioner
+//executed step is recorded as applied and written in journal if enabled
+func (s *Shifter) execNoTx(conn Executor, c Change) (err error) {
+	var (
+		db   Querier = conn
+		sess Session
+	)
+	timeoutSQL := s.getSessionTimeoutSQL()
+	if timeoutSQL != "" {
+		if sess, err = getSession(conn); err == nil && sess != nil {
+			defer sess.Close()
+			if _, err = sess.Exec(timeoutSQL); err == nil {
+				db = sess
+				defer sess.Exec(s.getSessionResetSQL())
+			}
+		}
+	}
+	if err == nil {
+		if _, err = s.execResult(db, c); err == nil {
+			s.applied = append(s.applied, s.run.executed...)
+		}
+	} else {
+		err = getWrapError(c.Table, "session timeout", timeoutSQL, err)
+	}
+	s.run.executed = nil
+	if jErr := s.writeJournal(conn, true); err == nil {
+		err = jErr
+	}
+	return
+}
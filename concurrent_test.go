@@ -0,0 +1,87 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentIndex(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter().Concurrent(true).Schema("tenant_1")
+	s.run.dryRun = true
+	idx := model.Index{IdxName: "idx_test_user_email", IType: GinIndex, Columns: "email"}
+	_, err := s.addIndex(nil, "test_user", idx, true)
+	assert.NoError(err)
+	_, err = s.dropIndex(nil, "test_user", idx, true)
+	assert.NoError(err)
+	_, err = s.addCompositeUK(nil, "test_user", map[string]string{
+		"test_user_email_name_key": "email,name"}, true)
+	assert.NoError(err)
+	//nothing is executed in transaction
+	assert.Empty(s.run.changes)
+	assert.NoError(s.execOnline(nil))
+	if assert.Len(s.run.changes, 4) {
+		assert.True(s.run.changes[0].NoTx)
+		assert.Equal("CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_test_user_email "+
+			"ON tenant_1.test_user USING gin (email);\n", s.run.changes[0].SQL)
+		assert.Equal("DROP INDEX IF EXISTS idx_test_user_email;\n", s.run.changes[0].DownSQL)
+		assert.Equal("DROP INDEX CONCURRENTLY IF EXISTS tenant_1.idx_test_user_email;\n",
+			s.run.changes[1].SQL)
+		assert.Equal("CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS test_user_email_name_key "+
+			"ON tenant_1.test_user (email,name);\n", s.run.changes[2].SQL)
+		assert.False(s.run.changes[3].NoTx)
+		assert.Equal("ALTER TABLE test_user ADD CONSTRAINT test_user_email_name_key "+
+			"UNIQUE USING INDEX test_user_email_name_key;\n", s.run.changes[3].SQL)
+	}
+
+	//index of table created in the same run is created in transaction
+	s = NewShifter().Concurrent(true)
+	s.run.dryRun = true
+	s.run.newTables["test_user"] = struct{}{}
+	_, err = s.addIndex(nil, "test_user", idx, true)
+	assert.NoError(err)
+	assert.Empty(s.run.online)
+	if assert.Len(s.run.changes, 1) {
+		assert.Equal("CREATE INDEX IF NOT EXISTS idx_test_user_email ON test_user USING gin (email);\n",
+			s.run.changes[0].SQL)
+	}
+}
+
+func TestConcurrentRerun(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter().Concurrent(true)
+	addAllTables(s)
+	var (
+		ukName  string
+		columns []model.ColSchema
+	)
+	for name := range s.getUKFromMethod("test_address") {
+		ukName = name
+	}
+	for _, col := range s.getStructSchema("test_address") {
+		columns = append(columns, col)
+	}
+	//unique index left invalid by failed concurrent build
+	snap := Snapshot{
+		Tables: map[string]TableSnapshot{
+			"test_address":         {Columns: columns, InvalidIndexes: []string{ukName}},
+			"test_address_history": {},
+		},
+		Enums: map[string][]string{"address_status": {"enable", "disable"}},
+	}
+	changes, err := s.PlanOffline(snap, "test_address")
+	assert.NoError(err)
+	dropIdx, createIdx := -1, -1
+	for i, c := range changes {
+		if c.Kind == DropIndexChange && c.SQL == "DROP INDEX CONCURRENTLY IF EXISTS "+ukName+";\n" {
+			dropIdx = i
+		} else if c.Kind == AddUniqueKeyChange && c.NoTx {
+			createIdx = i
+		}
+	}
+	//invalid index is dropped before unique index is built again
+	assert.NotEqual(-1, dropIdx)
+	assert.True(dropIdx < createIdx)
+}
@@ -39,6 +39,11 @@ const (
 	primaryKeySuffix    = "pkey"
 	uniqueKeySuffix     = "key"
 	foreignKeySuffix    = "fkey"
+	checkSuffix         = "check"
+	checkTag            = "check:"
+	notNullSuffix       = "not_null"
+	defaultBatchSize    = 1000
+	skipTriggerSetting  = "pg_shifter.skip_trigger"
 	TriggerTag          = "trigger" //use to create triggers on table.
 	HistoryTag          = "history" //use to create history table. Default table_history if after trigger given
 	afterInsertTrigger  = "ai"
@@ -46,4 +51,5 @@ const (
 	afterDeleteTrigger  = "ad"
 	beforeUpdateTrigger = "bu"
 	curPkg              = "shifter \"github.com/mayur-tolexo/pg-shifter\""
+	modelPkg            = "\"github.com/mayur-tolexo/pg-shifter/model\""
 )
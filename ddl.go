@@ -0,0 +1,80 @@
+package shifter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DDL will return the sql script creating the given tables from scratch.
+// Script is deterministic and dependency ordered i.e. enums, tables with
+// constraints and composite keys, history tables, triggers, indexes and
+// composite unique keys, then foreign keys which form a dependency cycle.
+// Database is not needed as tables are planned against an empty catalog.
+//
+// Parameters
+//  models: struct pointers or strings (table names)
+// if models are not given then all the tables set in shifter using SetTableModels() are exported
+func (s *Shifter) DDL(models ...interface{}) (ddl string, err error) {
+	s.lock()
+	defer s.unlock()
+	var tables []string
+	if tables, err = s.getTableNames(models); err == nil {
+		s.run.catalog = Snapshot{}
+		s.run.dryRun = true
+		if err = s.createDDL(tables); err == nil {
+			ddl = s.getDDLScript(s.run.changes)
+		}
+	}
+	return
+}
+
+//createDDL will record the creation of given tables in dependency order
+//dependency is resolved on all registered tables so that cycles are deferred
+func (s *Shifter) createDDL(tables []string) (err error) {
+	selected := make(map[string]struct{})
+	for _, tableName := range tables {
+		if _, exists := s.table[tableName]; exists == false {
+			err = errors.New("Invalid Table Name: " + tableName)
+			break
+		}
+		selected[tableName] = struct{}{}
+	}
+	if err == nil {
+		dep := s.getDependency()
+		s.run.deferredFK = dep.deferred
+		for _, tableName := range dep.order {
+			if _, exists := selected[tableName]; exists {
+				if err = s.createTable(nil, tableName, false); err == nil {
+					if err = s.createIndex(nil, tableName, true); err == nil {
+						uk := s.getUKFromMethod(tableName)
+						_, err = s.addCompositeUK(nil, tableName, uk, true)
+					}
+				}
+				if err != nil {
+					break
+				}
+			}
+		}
+		if err == nil {
+			if err = s.addPendingFK(nil); err == nil {
+				err = s.execOnline(nil)
+			}
+		}
+		s.run.deferredFK = nil
+	}
+	return
+}
+
+//getDDLScript will return sql script of the changes
+//each statement is preceded by comment having change kind and table
+func (s *Shifter) getDDLScript(changes []Change) string {
+	var sql []string
+	if s.schema != "" {
+		sql = append(sql, getSessionSearchPathSQL(s.schema)+";\n")
+	}
+	for _, c := range changes {
+		sql = append(sql, fmt.Sprintf("-- %v %v\n%v\n", c.Kind, c.Table, strings.TrimSpace(c.SQL)))
+	}
+	return strings.Join(sql, "\n")
+}
@@ -0,0 +1,40 @@
+package shifter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDDL(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&db.TestAddress{}, &db.TestAdminUser{}, &db.TestUser{})
+	ddl, err := s.DDL()
+	assert.NoError(err)
+
+	//referenced table and enum are created before the table using them
+	user := strings.Index(ddl, "CREATE TABLE IF NOT EXISTS test_user ")
+	address := strings.Index(ddl, "CREATE TABLE IF NOT EXISTS test_address ")
+	enum := strings.Index(ddl, "CREATE type address_status")
+	assert.True(user >= 0 && address > user)
+	assert.True(enum >= 0 && enum < address)
+	assert.Contains(ddl, "-- create history table test_address_history")
+	assert.Contains(ddl, "-- create trigger test_address")
+
+	//script is same on every run
+	again, err := s.DDL()
+	assert.NoError(err)
+	assert.Equal(ddl, again)
+
+	//foreign keys forming a cycle are added after tables are created
+	s = NewShifter(&localOrder{}, &localInvoice{})
+	ddl, err = s.DDL()
+	assert.NoError(err)
+	fk := strings.Index(ddl, "-- add constraint local_invoice")
+	assert.True(fk > strings.Index(ddl, "CREATE TABLE IF NOT EXISTS local_order "))
+
+	_, err = s.DDL("test_unknown")
+	assert.Error(err)
+}
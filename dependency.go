@@ -0,0 +1,278 @@
+package shifter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//fkRef is the foreign key reference of table column
+type fkRef struct {
+	schema model.ColSchema
+	//clause is the references clause of column in struct sql tag
+	clause string
+	//key is the multi column foreign key of struct ForeignKeys() method
+	key *model.KeySchema
+}
+
+//dependency is the table dependency graph
+type dependency struct {
+	order    []string
+	cycles   [][]string
+	deferred map[string][]fkRef
+}
+
+// DependencyOrder will return the registered table names in dependency order
+// i.e. referenced table comes before the table having foreign key on it.
+// Dependency cycles including self references are also returned.
+// Foreign keys which form a cycle are added after all tables are created in CreateAllTable().
+func (s *Shifter) DependencyOrder() (order []string, cycles [][]string) {
+	s.lock()
+	defer s.unlock()
+	dep := s.getDependency()
+	order, cycles = dep.order, dep.cycles
+	return
+}
+
+//getDependency will return dependency graph of registered tables
+//sorted using kahn's algorithm. In case of cycle the smallest table name
+//on the cycle is taken first and its foreign keys on the cycle are deferred
+func (s *Shifter) getDependency() (dep dependency) {
+	tables, _ := s.getTableNames(nil)
+	refs := make(map[string][]fkRef)
+	deps := make(map[string]map[string]struct{})
+	dependents := make(map[string][]string)
+	for _, tableName := range tables {
+		refs[tableName] = s.getTableRefs(tableName)
+		deps[tableName] = make(map[string]struct{})
+		for _, ref := range refs[tableName] {
+			refTable := ref.schema.ForeignTableName
+			if refTable == tableName {
+				dep.cycles = append(dep.cycles, []string{tableName, tableName})
+			} else if _, exists := deps[tableName][refTable]; exists == false {
+				deps[tableName][refTable] = struct{}{}
+				dependents[refTable] = append(dependents[refTable], tableName)
+			}
+		}
+	}
+
+	dep.deferred = make(map[string][]fkRef)
+	done := make(map[string]struct{})
+	inDegree := make(map[string]int)
+	for _, tableName := range tables {
+		inDegree[tableName] = len(deps[tableName])
+	}
+	resolve := func(tableName string) {
+		done[tableName] = struct{}{}
+		dep.order = append(dep.order, tableName)
+		for _, dependent := range dependents[tableName] {
+			inDegree[dependent]--
+		}
+	}
+
+	for len(dep.order) < len(tables) {
+		ready := ""
+		for _, tableName := range tables {
+			if _, exists := done[tableName]; exists == false && inDegree[tableName] == 0 {
+				ready = tableName
+				break
+			}
+		}
+		if ready == "" {
+			//all remaining tables are on or behind a cycle
+			for _, tableName := range tables {
+				if _, exists := done[tableName]; exists == false {
+					if cycle := findCycle(tableName, deps, done); len(cycle) > 0 {
+						ready = tableName
+						dep.cycles = append(dep.cycles, cycle)
+						break
+					}
+				}
+			}
+			for _, ref := range refs[ready] {
+				refTable := ref.schema.ForeignTableName
+				if _, exists := done[refTable]; exists == false && refTable != ready {
+					dep.deferred[ready] = append(dep.deferred[ready], ref)
+				}
+			}
+		}
+		resolve(ready)
+	}
+	return
+}
+
+//findCycle will return cycle path from table to itself
+//through tables which are not done yet
+func findCycle(tableName string, deps map[string]map[string]struct{},
+	done map[string]struct{}) (cycle []string) {
+
+	visited := make(map[string]struct{})
+	var visit func(cur string, path []string) []string
+	visit = func(cur string, path []string) []string {
+		path = append(path, cur)
+		for _, refTable := range getSortedKeys(deps[cur]) {
+			if _, exists := done[refTable]; exists {
+				continue
+			}
+			if refTable == tableName {
+				return append(path, refTable)
+			}
+			if _, exists := visited[refTable]; exists == false {
+				visited[refTable] = struct{}{}
+				if found := visit(refTable, path); len(found) > 0 {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	cycle = visit(tableName, nil)
+	return
+}
+
+//getSortedKeys will return map keys in sorted order
+func getSortedKeys(m map[string]struct{}) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+//getTableRefs will return foreign key references of table on registered tables
+//in column name order
+func (s *Shifter) getTableRefs(tableName string) (refs []fkRef) {
+	sSchema := s.getStructSchema(tableName)
+	for _, field := range util.GetStructField(s.table[tableName]) {
+		refTable := util.RefTable(field)
+		if _, exists := s.table[refTable]; exists {
+			tag := field.Tag.Get("sql")
+			schema := sSchema[getColName(strings.ToLower(tag))]
+			refs = append(refs, fkRef{schema: schema, clause: getRefClause(tag)})
+		}
+	}
+	for _, key := range s.getCompositeKeyFromMethod(tableName) {
+		if _, exists := s.table[key.ForeignTableName]; exists && key.ConstraintType == foreignKey {
+			fk := key
+			schema := model.ColSchema{TableName: tableName, ColumnName: key.Columns,
+				ConstraintType: foreignKey, ForeignTableName: key.ForeignTableName}
+			refs = append(refs, fkRef{schema: schema, key: &fk})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].schema.ColumnName < refs[j].schema.ColumnName
+	})
+	return
+}
+
+//getRefClause will return references clause from struct sql tag
+//i.e. REFERENCES test_user(user_id) ON DELETE RESTRICT
+func getRefClause(tag string) (clause string) {
+	if idx := strings.Index(strings.ToLower(tag), referencesTag); idx >= 0 {
+		clause = tag[idx:]
+		depth := 0
+		for i, ch := range clause {
+			if ch == '(' {
+				depth++
+			} else if ch == ')' {
+				depth--
+			} else if ch == ',' && depth == 0 {
+				clause = clause[:i]
+				break
+			}
+		}
+		clause = strings.TrimSpace(clause)
+	}
+	return
+}
+
+//removeDeferredFK will remove deferred foreign key references from create table sql
+//removed foreign keys are added after all tables are created
+func (s *Shifter) removeDeferredFK(tableName, sql string) string {
+	for _, ref := range s.run.deferredFK[tableName] {
+		if ref.key == nil {
+			sql = strings.Replace(sql, " "+ref.clause, "", 1)
+		}
+		s.run.pendingFK = append(s.run.pendingFK, ref)
+	}
+	return sql
+}
+
+//getCreateCompositeKey will return composite primary/foreign keys of struct
+//need to add on table creation. Deferred foreign keys are added after all tables are created
+func (s *Shifter) getCreateCompositeKey(tableName string) (key map[string]model.KeySchema) {
+	key = s.getCompositeKeyFromMethod(tableName)
+	for _, ref := range s.run.deferredFK[tableName] {
+		if ref.key != nil {
+			delete(key, ref.key.ConstraintName)
+		}
+	}
+	return
+}
+
+//addPendingFK will add the foreign keys removed from create table sql
+func (s *Shifter) addPendingFK(tx Tx) (err error) {
+	for _, ref := range s.run.pendingFK {
+		var c Change
+		if ref.key != nil {
+			c = newChange(AddCompositeKeyChange, ref.key.TableName, ref.key.Columns,
+				getAddCompositeKeySQL(*ref.key))
+			c.DownSQL = getDropConstraintSQL(ref.key.TableName, ref.key.ConstraintName)
+		} else {
+			schema := ref.schema
+			schema.ConstraintType = foreignKey
+			sql := getAlterAddConstraintSQL(schema)
+			c = newChange(AddConstraintChange, schema.TableName, schema.ColumnName, sql)
+			c.DownSQL = getDropConstraintSQL(schema.TableName, getConstraintName(schema))
+		}
+		if err = s.exec(tx, c); err != nil {
+			break
+		}
+	}
+	s.run.pendingFK = nil
+	return
+}
+
+//dropDeferredFK will drop the foreign keys which form a cycle
+//so that tables on cycle can be dropped without cascade
+func (s *Shifter) dropDeferredFK(tx Tx, deferred map[string][]fkRef) (err error) {
+	tables, _ := s.getTableNames(nil)
+	for _, tableName := range tables {
+		for _, ref := range deferred[tableName] {
+			schema := ref.schema
+			schema.ConstraintType = foreignKey
+			if s.catalog(tx).TableExists(tableName) {
+				kind, name, downSQL := DropConstraintChange, getConstraintName(schema),
+					getAlterAddConstraintSQL(schema)
+				if ref.key != nil {
+					kind, name, downSQL = DropCompositeKeyChange, ref.key.ConstraintName,
+						getAddCompositeKeySQL(*ref.key)
+				}
+				sql := fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT IF EXISTS %v;\n",
+					tableName, name)
+				c := newChange(kind, tableName, schema.ColumnName, sql)
+				c.DownSQL = downSQL
+				if err = s.exec(tx, c); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return
+}
+
+//printCycles will print the dependency cycles whose foreign keys are deferred
+func printCycles(dep dependency) {
+	for _, cycle := range dep.cycles {
+		if len(dep.deferred[cycle[0]]) > 0 {
+			fmt.Printf("Dependency cycle: %v (foreign keys of %v added after table creation)\n",
+				strings.Join(cycle, " -> "), cycle[0])
+		}
+	}
+}
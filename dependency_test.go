@@ -0,0 +1,52 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+type localOrder struct {
+	tableName struct{} `sql:"local_order"`
+	OrderID   int      `sql:"order_id,type:serial PRIMARY KEY"`
+	InvoiceID int      `sql:"invoice_id,type:int NULL REFERENCES local_invoice(invoice_id) DEFERRABLE INITIALLY DEFERRED"`
+}
+
+type localInvoice struct {
+	tableName struct{} `sql:"local_invoice"`
+	InvoiceID int      `sql:"invoice_id,type:serial PRIMARY KEY"`
+	OrderID   int      `sql:"order_id,type:int NOT NULL REFERENCES local_order(order_id)"`
+}
+
+func TestDependencyOrder(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&db.TestAddress{}, &db.TestAdminUser{}, &db.TestUser{})
+	order, cycles := s.DependencyOrder()
+	assert.Equal([]string{"test_user", "test_address", "test_admin_user"}, order)
+	assert.Equal([][]string{{"test_user", "test_user"}}, cycles)
+
+	s = NewShifter(&localOrder{}, &localInvoice{})
+	dep := s.getDependency()
+	assert.Equal([]string{"local_invoice", "local_order"}, dep.order)
+	assert.Equal([][]string{{"local_invoice", "local_order", "local_invoice"}}, dep.cycles)
+	if assert.Len(dep.deferred["local_invoice"], 1) {
+		ref := dep.deferred["local_invoice"][0]
+		assert.Equal("order_id", ref.schema.ColumnName)
+		assert.Equal("REFERENCES local_order(order_id)", ref.clause)
+	}
+
+	s.run.deferredFK = dep.deferred
+	sql, err := getCreateTableSQL(&localInvoice{})
+	assert.NoError(err)
+	sql = s.removeDeferredFK("local_invoice", sql)
+	assert.NotContains(sql, "REFERENCES")
+	assert.Len(s.run.pendingFK, 1)
+}
+
+func TestGetRefClause(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("REFERENCES test_user(user_id) ON DELETE RESTRICT",
+		getRefClause("created_by,type:int NOT NULL REFERENCES test_user(user_id) ON DELETE RESTRICT,notnull"))
+	assert.Equal("", getRefClause("name,type:varchar(255)"))
+}
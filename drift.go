@@ -0,0 +1,392 @@
+package shifter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/flaw"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//triggerRegex matches trigger name in create trigger sql
+var triggerRegex = regexp.MustCompile(`CREATE TRIGGER\s+(\S+)`)
+
+//DriftKind is the kind of difference between struct and database
+type DriftKind string
+
+//drift kinds
+const (
+	MissingTableDrift  DriftKind = "missing table"
+	MissingColumnDrift DriftKind = "missing column"
+	ExtraColumnDrift   DriftKind = "extra column"
+	DataTypeDrift      DriftKind = "data type"
+	DefaultDrift       DriftKind = "default"
+	NullableDrift      DriftKind = "nullable"
+	ConstraintDrift    DriftKind = "constraint"
+	CompositeKeyDrift  DriftKind = "composite key"
+	UniqueKeyDrift     DriftKind = "unique key"
+	CheckDrift         DriftKind = "check"
+	IndexDrift         DriftKind = "index"
+	EnumDrift          DriftKind = "enum"
+	TriggerDrift       DriftKind = "trigger"
+	driftNotInDB                 = "<none>"
+	driftNotInStruct             = "<not in struct>"
+)
+
+//Drift is a difference between table struct and database
+type Drift struct {
+	Table    string    `json:"table"`
+	Kind     DriftKind `json:"kind"`
+	Name     string    `json:"name,omitempty"`
+	Expected string    `json:"expected"`
+	Actual   string    `json:"actual"`
+}
+
+//DriftReport is the list of differences between table structs and database
+type DriftReport struct {
+	Drift []Drift `json:"drift"`
+}
+
+//HasDrift will check database is different from table structs
+func (r DriftReport) HasDrift() bool {
+	return len(r.Drift) > 0
+}
+
+//Text will return the report in readable form, one line per difference
+func (r DriftReport) Text() string {
+	var b strings.Builder
+	if r.HasDrift() == false {
+		b.WriteString("no drift\n")
+	}
+	for _, d := range r.Drift {
+		name := d.Table
+		if d.Name != "" {
+			name += " " + d.Name
+		}
+		fmt.Fprintf(&b, "%v %v: expected %v, actual %v\n", name, d.Kind, d.Expected, d.Actual)
+	}
+	return b.String()
+}
+
+//JSON will return the report in json
+func (r DriftReport) JSON() ([]byte, error) {
+	if r.Drift == nil {
+		r.Drift = []Drift{}
+	}
+	return json.MarshalIndent(r, "", "  ")
+}
+
+//add will add the difference in report
+func (r *DriftReport) add(table string, kind DriftKind, name, expected, actual string) {
+	r.Drift = append(r.Drift, Drift{Table: table, Kind: kind, Name: name,
+		Expected: expected, Actual: actual})
+}
+
+// Drift will compare table structs with database and return the differences.
+// It covers columns, data type, default, nullability, constraints,
+// composite keys, unique keys, checks, indexes, enums and triggers.
+// Nothing is changed in database as comparison is done in a read only transaction.
+//
+// Parameters
+//  conn: postgresql connection
+//  models: struct pointers or strings (table names)
+// if models are not given then all the tables set in shifter using SetTableModels() are compared
+func (s *Shifter) Drift(conn *pg.DB, models ...interface{}) (report DriftReport, err error) {
+	s.lock()
+	defer s.unlock()
+	var (
+		tx     Tx
+		tables []string
+	)
+	enumChecked := make(map[string]struct{})
+	if tables, err = s.getTableNames(models); err == nil {
+		if tx, err = s.begin(s.getExecutor(conn)); err == nil {
+			if _, err = tx.Exec("SET TRANSACTION READ ONLY"); err == nil {
+				for _, tableName := range tables {
+					if err = s.tableDrift(tx, tableName, enumChecked, &report); err != nil {
+						break
+					}
+				}
+			}
+			tx.Rollback()
+		} else {
+			err = flaw.TxError(err)
+		}
+	}
+	return
+}
+
+//tableDrift will add differences of table struct and database table in report
+func (s *Shifter) tableDrift(tx Tx, tableName string,
+	enumChecked map[string]struct{}, report *DriftReport) (err error) {
+
+	var tSchema map[string]model.ColSchema
+	if err = s.enumDrift(tx, tableName, enumChecked, report); err == nil {
+		if s.catalog(tx).TableExists(tableName) == false {
+			report.add(tableName, MissingTableDrift, "", tableName, driftNotInDB)
+		} else if tSchema, err = s.getTableSchema(tx, tableName); err == nil {
+			columnDrift(tableName, tSchema, s.getStructSchema(tableName), report)
+			if err = s.keyDrift(tx, tableName, report); err == nil {
+				if err = s.indexDrift(tx, tableName, report); err == nil {
+					err = s.triggerDrift(tx, tableName, report)
+				}
+			}
+		}
+	}
+	return
+}
+
+//columnDrift will add differences of table and struct columns in report
+//columns are compared in the same way as alter table
+func columnDrift(tableName string, tSchema, sSchema map[string]model.ColSchema,
+	report *DriftReport) {
+
+	for _, col := range getSortedColumn(sSchema) {
+		scSchema := sSchema[col]
+		tcSchema, exists := tSchema[col]
+		if exists == false {
+			report.add(tableName, MissingColumnDrift, col, getAddColTypeSQL(scSchema), driftNotInDB)
+			continue
+		}
+		tDataType, sDataType := getStructDataType(tcSchema), getStructDataType(scSchema)
+		if tDataType != sDataType {
+			report.add(tableName, DataTypeDrift, col, sDataType, tDataType)
+		} else if tcSchema.ConstraintType != primaryKey && isSameDefault(tcSchema, scSchema) == false {
+			report.add(tableName, DefaultDrift, col, scSchema.ColumnDefault, tcSchema.ColumnDefault)
+		}
+		if tcSchema.IsNullable != scSchema.IsNullable {
+			report.add(tableName, NullableDrift, col, scSchema.IsNullable, tcSchema.IsNullable)
+		}
+		if tDef, sDef := getColConstraintDef(tcSchema), getColConstraintDef(scSchema); tDef != sDef {
+			report.add(tableName, ConstraintDrift, col, sDef, tDef)
+		}
+	}
+	for _, col := range getSortedColumn(tSchema) {
+		if _, exists := sSchema[col]; exists == false {
+			report.add(tableName, ExtraColumnDrift, col, driftNotInStruct,
+				getAddColTypeSQL(tSchema[col]))
+		}
+	}
+}
+
+//getSortedColumn will return column names of schema in sorted order
+func getSortedColumn(schema map[string]model.ColSchema) (cols []string) {
+	for col := range schema {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	return
+}
+
+//getColConstraintDef will return column constraint definition to compare
+//table and struct column constraint
+func getColConstraintDef(schema model.ColSchema) (def string) {
+	def = schema.ConstraintType
+	if schema.ConstraintType == foreignKey {
+		def += fmt.Sprintf(" %v(%v) ON DELETE %v ON UPDATE %v", schema.ForeignTableName,
+			schema.ForeignColumnName, getConstraintTagByFlag(schema.DeleteType),
+			getConstraintTagByFlag(schema.UpdateType))
+		if schema.IsFkUnique {
+			def += " " + uniqueKey
+		}
+	}
+	if def != "" {
+		def += getDefferSQL(schema)
+	} else {
+		def = driftNotInDB
+	}
+	return
+}
+
+//keyDrift will add differences of composite primary/foreign keys,
+//composite unique keys and checks in report
+func (s *Shifter) keyDrift(tx Tx, tableName string, report *DriftReport) (err error) {
+	var (
+		tKey   []model.KeySchema
+		tUK    []model.UKSchema
+		tCheck []model.CheckSchema
+	)
+	if tKey, err = s.catalog(tx).CompositeKey(tableName); err == nil {
+		sKey := s.getCompositeKeyFromMethod(tableName)
+		for _, curTableKey := range tKey {
+			if name, exists := getKeyByDefinition(sKey, curTableKey); exists {
+				delete(sKey, name)
+			} else {
+				report.add(tableName, CompositeKeyDrift, curTableKey.ConstraintName,
+					driftNotInStruct, strings.TrimSpace(getAddCompositeKeySQL(curTableKey)))
+			}
+		}
+		for _, name := range getSortedKeyName(sKey) {
+			report.add(tableName, CompositeKeyDrift, name,
+				strings.TrimSpace(getAddCompositeKeySQL(sKey[name])), driftNotInDB)
+		}
+	}
+	if err == nil {
+		if tUK, err = s.catalog(tx).CompositeUniqueKey(tableName); err == nil {
+			ukDrift(tableName, tUK, s.getUKFromMethod(tableName), report)
+		}
+	}
+	if err == nil {
+		if tCheck, err = s.catalog(tx).Check(tableName); err == nil {
+			sCheck := s.getCheckFromStruct(tableName)
+			for _, curTableCheck := range tCheck {
+				expr, exists := sCheck[curTableCheck.ConstraintName]
+				if exists == false {
+					report.add(tableName, CheckDrift, curTableCheck.ConstraintName,
+						driftNotInStruct, curTableCheck.Definition)
+				} else if normalizeCheck(expr) != normalizeCheck(curTableCheck.Definition) {
+					report.add(tableName, CheckDrift, curTableCheck.ConstraintName,
+						"CHECK ("+expr+")", curTableCheck.Definition)
+				}
+				delete(sCheck, curTableCheck.ConstraintName)
+			}
+			for _, name := range getSortedCheckName(sCheck) {
+				report.add(tableName, CheckDrift, name, "CHECK ("+sCheck[name]+")", driftNotInDB)
+			}
+		}
+	}
+	return
+}
+
+//getSortedKeyName will return key names in sorted order
+func getSortedKeyName(keys map[string]model.KeySchema) (names []string) {
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+//ukDrift will add differences of table and struct composite unique keys in report
+//unique keys are compared by columns
+func ukDrift(tableName string, tUK []model.UKSchema, sUK map[string]string,
+	report *DriftReport) {
+
+	for _, curTableUK := range tUK {
+		tFields := getTrimmedColumns(curTableUK.Columns)
+		if ukName, exists := getUKNameByFields(sUK, tFields); exists {
+			delete(sUK, ukName)
+		} else {
+			report.add(tableName, UniqueKeyDrift, curTableUK.ConstraintName,
+				driftNotInStruct, tFields)
+		}
+	}
+	for _, ukName := range getSortedCheckName(sUK) {
+		if isCompositeUk(sUK[ukName]) {
+			report.add(tableName, UniqueKeyDrift, ukName, sUK[ukName], driftNotInDB)
+		}
+	}
+}
+
+//indexDrift will add differences of table and struct indexes in report
+func (s *Shifter) indexDrift(tx Tx, tableName string, report *DriftReport) (err error) {
+	var (
+		tIdx    []model.Index
+		invalid []string
+	)
+	sIdx := s.getStructIndex(tableName)
+	if tIdx, err = s.catalog(tx).Index(tableName); err == nil {
+		for _, curTableIdx := range tIdx {
+			curStructIdx, exists := sIdx[curTableIdx.IdxName]
+			if exists == false {
+				report.add(tableName, IndexDrift, curTableIdx.IdxName, driftNotInStruct,
+					getIndexDef(curTableIdx.IType, curTableIdx.Columns))
+			} else if isSameIndex(curTableIdx, curStructIdx) == false {
+				report.add(tableName, IndexDrift, curTableIdx.IdxName,
+					getIndexDef(curStructIdx.IType, curStructIdx.Columns),
+					getIndexDef(curTableIdx.IType, curTableIdx.Columns))
+			}
+			delete(sIdx, curTableIdx.IdxName)
+		}
+		if invalid, err = s.catalog(tx).InvalidIndex(tableName); err == nil {
+			for _, idxName := range invalid {
+				report.add(tableName, IndexDrift, idxName, driftNotInStruct, "INVALID")
+			}
+			for _, idxName := range getSortedIndexName(sIdx) {
+				report.add(tableName, IndexDrift, idxName,
+					getIndexDef(sIdx[idxName].IType, sIdx[idxName].Columns), driftNotInDB)
+			}
+		}
+	}
+	return
+}
+
+//getIndexDef will return index definition i.e. btree (email)
+func getIndexDef(iType, columns string) string {
+	return fmt.Sprintf("%v (%v)", getIndexType(iType), getTrimmedColumns(columns))
+}
+
+//enumDrift will add differences of struct enums and database enums in report
+//each enum is compared once even if used in multiple tables
+func (s *Shifter) enumDrift(tx Tx, tableName string,
+	enumChecked map[string]struct{}, report *DriftReport) (err error) {
+
+	var enumNames []string
+	for _, refFeild := range util.GetStructField(s.table[tableName]) {
+		fType := util.FieldType(refFeild)
+		typeName := s.getEnumTypeName(tableName, fType)
+		if _, checked := enumChecked[typeName]; checked == false && s.isEnum(tableName, fType) {
+			enumChecked[typeName] = struct{}{}
+			enumNames = append(enumNames, fType)
+		}
+	}
+	sort.Strings(enumNames)
+	for _, enumName := range enumNames {
+		var sEnumValue, tEnumValue []string
+		if sEnumValue, err = s.getEnum(tableName, enumName); err != nil {
+			break
+		}
+		expected := strings.Join(sEnumValue, ",")
+		typeName := s.getEnumTypeName(tableName, enumName)
+		if s.catalog(tx).EnumExists(typeName) == false {
+			report.add(tableName, EnumDrift, typeName, expected, driftNotInDB)
+		} else if tEnumValue, err = s.catalog(tx).EnumValue(typeName); err != nil {
+			break
+		} else if isSameEnumValue(sEnumValue, tEnumValue) == false {
+			report.add(tableName, EnumDrift, typeName, expected, strings.Join(tEnumValue, ","))
+		}
+	}
+	return
+}
+
+//isSameEnumValue will check both enums have same values irrespective of order
+func isSameEnumValue(sEnumValue, tEnumValue []string) bool {
+	expected := append([]string{}, sEnumValue...)
+	actual := append([]string{}, tEnumValue...)
+	sort.Strings(expected)
+	sort.Strings(actual)
+	return strings.Join(expected, ",") == strings.Join(actual, ",")
+}
+
+//triggerDrift will add triggers of struct missing in database in report
+func (s *Shifter) triggerDrift(tx Tx, tableName string, report *DriftReport) (err error) {
+	var tTrigger []string
+	if tTrigger, err = s.catalog(tx).Trigger(tableName); err == nil {
+		exists := make(map[string]struct{})
+		for _, name := range tTrigger {
+			exists[name] = struct{}{}
+		}
+		for _, name := range s.getStructTrigger(tableName) {
+			if _, found := exists[name]; found == false {
+				report.add(tableName, TriggerDrift, name, name, driftNotInDB)
+			}
+		}
+	}
+	return
+}
+
+//getStructTrigger will return trigger names which shifter creates on table
+func (s *Shifter) getStructTrigger(tableName string) (names []string) {
+	if s.isSkip(tableName) == false {
+		for _, match := range triggerRegex.FindAllStringSubmatch(s.getTrigger(tableName), -1) {
+			names = append(names, match[1])
+		}
+		sort.Strings(names)
+	}
+	return
+}
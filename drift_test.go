@@ -0,0 +1,76 @@
+package shifter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mayur-tolexo/contour/adapter/psql"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrift(t *testing.T) {
+	if conn, err := psql.Conn(true); err == nil {
+		s := NewShifter()
+		addAllTables(s)
+		assert := assert.New(t)
+		report, err := s.Drift(conn)
+		assert.NoError(err)
+		for _, d := range report.Drift {
+			assert.NotEmpty(d.Table)
+			assert.NotEmpty(d.Kind)
+		}
+	}
+}
+
+func TestColumnDrift(t *testing.T) {
+	assert := assert.New(t)
+	sSchema := map[string]model.ColSchema{
+		"id":    {ColumnName: "id", DataType: "integer", IsNullable: "NO"},
+		"email": {ColumnName: "email", DataType: "text", IsNullable: "NO"},
+	}
+	tSchema := map[string]model.ColSchema{
+		"id":   {ColumnName: "id", DataType: "integer", IsNullable: "YES"},
+		"name": {ColumnName: "name", DataType: "text", IsNullable: "YES"},
+	}
+	var report DriftReport
+	columnDrift("test_user", tSchema, sSchema, &report)
+	assert.Equal([]Drift{
+		{Table: "test_user", Kind: MissingColumnDrift, Name: "email",
+			Expected: "text NOT NULL", Actual: driftNotInDB},
+		{Table: "test_user", Kind: NullableDrift, Name: "id", Expected: "NO", Actual: "YES"},
+		{Table: "test_user", Kind: ExtraColumnDrift, Name: "name",
+			Expected: driftNotInStruct, Actual: "text NULL"},
+	}, report.Drift)
+}
+
+func TestDriftReport(t *testing.T) {
+	assert := assert.New(t)
+	var report DriftReport
+	assert.False(report.HasDrift())
+	assert.Equal("no drift\n", report.Text())
+	data, err := report.JSON()
+	assert.NoError(err)
+	assert.JSONEq(`{"drift":[]}`, string(data))
+
+	report.add("test_user", EnumDrift, "user_type", "admin,user", "admin")
+	assert.True(report.HasDrift())
+	assert.Equal("test_user user_type enum: expected admin,user, actual admin\n", report.Text())
+	data, err = report.JSON()
+	assert.NoError(err)
+	var decoded DriftReport
+	assert.NoError(json.Unmarshal(data, &decoded))
+	assert.Equal(report, decoded)
+}
+
+func TestGetStructTrigger(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isSameEnumValue([]string{"a", "b"}, []string{"b", "a"}))
+	assert.False(isSameEnumValue([]string{"a", "b"}, []string{"a"}))
+
+	s := NewShifter()
+	addAllTables(s)
+	for _, name := range s.getStructTrigger("test_address") {
+		assert.Contains(s.getTrigger("test_address"), "CREATE TRIGGER "+name)
+	}
+}
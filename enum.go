@@ -6,15 +6,15 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/pg-shifter/model"
 	"github.com/mayur-tolexo/pg-shifter/util"
 )
 
 //upsertAllEnum will create/update all enum of the given table
-func (s *Shifter) upsertAllEnum(tx *pg.Tx, tableName string) (err error) {
+func (s *Shifter) upsertAllEnum(tx Tx, tableName string) (err error) {
 
 	tableModel := s.table[tableName]
-	fields := util.GetStructField(tableModel)
+	fields := util.GetSortedStructField(tableModel)
 
 	for _, refFeild := range fields {
 		fType := util.FieldType(refFeild)
@@ -28,7 +28,7 @@ func (s *Shifter) upsertAllEnum(tx *pg.Tx, tableName string) (err error) {
 }
 
 //dropAllEnum will drop all enum associated to table
-func (s *Shifter) dropAllEnum(tx *pg.Tx, tableName string, skipPrompt bool) (
+func (s *Shifter) dropAllEnum(tx Tx, tableName string, skipPrompt bool) (
 	err error) {
 
 	tableModel := s.table[tableName]
@@ -41,7 +41,7 @@ func (s *Shifter) dropAllEnum(tx *pg.Tx, tableName string, skipPrompt bool) (
 		// fmt.Println(enm, enm[fType])
 		if s.isEnum(tableName, fType) {
 			// fmt.Println("IN for ", fType)
-			if _, err = dropEnum(tx, tableName, fType, skipPrompt); err != nil {
+			if _, err = s.dropEnum(tx, tableName, fType, skipPrompt); err != nil {
 				break
 			}
 		}
@@ -50,16 +50,17 @@ func (s *Shifter) dropAllEnum(tx *pg.Tx, tableName string, skipPrompt bool) (
 }
 
 //upsertEnum will create/update enum of the given table
-func (s *Shifter) upsertEnum(tx *pg.Tx, tableName string,
+func (s *Shifter) upsertEnum(tx Tx, tableName string,
 	enumName string) (err error) {
 
 	var sEnumValue []string
 	if sEnumValue, err = s.getEnum(tableName, enumName); err == nil {
-		if _, created := enumCreated[enumName]; created == false {
-			if enumSQL, enumExists := getEnumQuery(tx, enumName, sEnumValue); enumExists == false {
-				err = s.createEnum(tx, tableName, enumName, enumSQL)
+		typeName := s.getEnumTypeName(tableName, enumName)
+		if _, created := s.run.enumCreated[typeName]; created == false {
+			if enumSQL, enumExists := getEnumQuery(s.catalog(tx), typeName, sEnumValue); enumExists == false {
+				err = s.createEnum(tx, tableName, typeName, enumSQL)
 			} else {
-				err = s.updateEnum(tx, tableName, enumName, sEnumValue)
+				err = s.updateEnum(tx, tableName, typeName, sEnumValue)
 			}
 		}
 	}
@@ -67,13 +68,14 @@ func (s *Shifter) upsertEnum(tx *pg.Tx, tableName string,
 }
 
 //Create Enum in database only if not exists
-func (s *Shifter) createEnumByName(tx *pg.Tx, tableName, enumName string) (err error) {
+func (s *Shifter) createEnumByName(tx Tx, tableName, enumName string) (err error) {
 
 	var enumValue []string
 	if enumValue, err = s.getEnum(tableName, enumName); err == nil {
-		if _, created := enumCreated[enumName]; created == false {
-			if enumSQL, enumExists := getEnumQuery(tx, enumName, enumValue); enumExists == false {
-				err = s.createEnum(tx, tableName, enumName, enumSQL)
+		typeName := s.getEnumTypeName(tableName, enumName)
+		if _, created := s.run.enumCreated[typeName]; created == false {
+			if enumSQL, enumExists := getEnumQuery(s.catalog(tx), typeName, enumValue); enumExists == false {
+				err = s.createEnum(tx, tableName, typeName, enumSQL)
 			}
 		}
 	}
@@ -81,27 +83,27 @@ func (s *Shifter) createEnumByName(tx *pg.Tx, tableName, enumName string) (err e
 }
 
 //createEnum will create enum
-func (s *Shifter) createEnum(tx *pg.Tx, tableName, enumName, enumSQL string) (err error) {
-	if _, err = tx.Exec(enumSQL); err == nil {
-		enumCreated[enumName] = struct{}{}
+func (s *Shifter) createEnum(tx Tx, tableName, enumName, enumSQL string) (err error) {
+	c := newChange(CreateEnumChange, tableName, "", enumSQL)
+	c.DownSQL = getDropEnumSQL(enumName)
+	if err = s.exec(tx, c); err == nil && s.run.dryRun == false {
+		s.run.enumCreated[enumName] = struct{}{}
 		fmt.Printf("Enum %v created\n", enumName)
-	} else {
-		err = getWrapError(tableName, "create enum", enumSQL, err)
 	}
 	return
 }
 
 //updateEnum will update enum if changed in enum map
-func (s *Shifter) updateEnum(tx *pg.Tx, tableName,
+func (s *Shifter) updateEnum(tx Tx, tableName,
 	enumName string, sEnumValue []string) (err error) {
 
 	var tEnumValue []string
-	if tEnumValue, err = getDBEnumValue(tx, enumName); err == nil {
+	if tEnumValue, err = s.catalog(tx).EnumValue(enumName); err == nil {
 
-		if _, err = addRemoveEnum(tx, tableName, enumName,
+		if _, err = s.addRemoveEnum(tx, tableName, enumName,
 			sEnumValue, tEnumValue, add); err == nil {
 
-			// _, err = addRemoveEnum(tx, tableName, enumName,
+			// _, err = s.addRemoveEnum(tx, tableName, enumName,
 			// 	tEnumValue, sEnumValue, drop)
 		}
 	}
@@ -109,7 +111,7 @@ func (s *Shifter) updateEnum(tx *pg.Tx, tableName,
 }
 
 //addRemoveEnum will add or remove enum which exists in a but not in b
-func addRemoveEnum(tx *pg.Tx, tableName, enumName string,
+func (s *Shifter) addRemoveEnum(tx Tx, tableName, enumName string,
 	a, b []string, op string) (isAlter bool, err error) {
 
 	var enumValueMap = make(map[string]struct{})
@@ -122,9 +124,9 @@ func addRemoveEnum(tx *pg.Tx, tableName, enumName string,
 		if _, exists := enumValueMap[curEnumVal]; exists == false {
 			switch op {
 			case add:
-				curIsAlter, err = addEnumVal(tx, tableName, enumName, curEnumVal)
+				curIsAlter, err = s.addEnumVal(tx, tableName, enumName, curEnumVal)
 			case drop:
-				curIsAlter, err = dropEnumVal(tx, tableName, enumName, curEnumVal)
+				curIsAlter, err = s.dropEnumVal(tx, tableName, enumName, curEnumVal)
 			}
 			if err != nil {
 				break
@@ -136,40 +138,57 @@ func addRemoveEnum(tx *pg.Tx, tableName, enumName string,
 }
 
 //addEnumVal will add enum value
-func addEnumVal(tx *pg.Tx, tableName, enumName string, value string) (
+func (s *Shifter) addEnumVal(tx Tx, tableName, enumName string, value string) (
 	isAlter bool, err error) {
 
-	sql := getEnumAddValSQL(enumName, value)
-	if isAlter, err = execByChoice(tx, sql, false); err != nil {
-		err = getWrapError(tableName, "add enum value", sql, err)
+	//enum is qualified by shifter schema as added enum value is written
+	//in its own migration file which doesn't set the search path
+	if schema := s.getTableSchemaName(tableName); schema != "" &&
+		strings.Contains(enumName, ".") == false {
+		enumName = schema + "." + enumName
 	}
+	sql := getEnumAddValSQL(enumName, value)
+	//added enum value can't be removed safely as rows and indexes may hold it
+	//so the change is irreversible
+	c := newChange(AddEnumValueChange, tableName, "", sql)
+	isAlter, err = s.execByChoice(tx, c, false)
 
 	return
 }
 
 //dropEnumVal will drop enum value
-func dropEnumVal(tx *pg.Tx, tableName, enumName string, value string) (
+func (s *Shifter) dropEnumVal(tx Tx, tableName, enumName string, value string) (
 	isAlter bool, err error) {
 
 	sql := getEnumDropValSQL(enumName, value)
-	if isAlter, err = execByChoice(tx, sql, false); err != nil {
-		err = getWrapError(tableName, "drop enum value", sql, err)
-	}
+	c := newChange(DropEnumValueChange, tableName, "", sql)
+	c.DownSQL = getEnumAddValSQL(enumName, value)
+	isAlter, err = s.execByChoice(tx, c, false)
 
 	return
 }
 
 //dropEnum will drop enum
-func dropEnum(tx *pg.Tx, tableName, enumName string, skipPrompt bool) (
+//reverse sql creates the enum with its values in database
+//so that the dropped type is restored even if it is different from struct
+func (s *Shifter) dropEnum(tx Tx, tableName, enumName string, skipPrompt bool) (
 	isAlter bool, err error) {
 
-	sql := fmt.Sprintf("DROP TYPE IF EXISTS %v;", enumName)
-	if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-		err = getWrapError(tableName, "drop enum", sql, err)
-	} else if isAlter {
-		fmt.Printf("Enum Dropped if exists: %v\n", enumName)
+	var enumValue []string
+	typeName := s.getEnumTypeName(tableName, enumName)
+	sql := getDropEnumSQL(typeName)
+	c := newChange(DropEnumChange, tableName, "", sql)
+	if s.catalog(tx).EnumExists(typeName) {
+		if enumValue, err = s.catalog(tx).EnumValue(typeName); err == nil {
+			c.DownSQL = fmt.Sprintf("CREATE type %v AS ENUM('%v');",
+				typeName, strings.Join(enumValue, "','"))
+		}
+	}
+	if err == nil {
+		if isAlter, err = s.execByChoice(tx, c, skipPrompt); err == nil && isAlter {
+			fmt.Printf("Enum Dropped if exists: %v\n", typeName)
+		}
 	}
-
 	return
 }
 
@@ -179,6 +198,12 @@ func getEnumAddValSQL(enumName string, value string) (sql string) {
 	return
 }
 
+//getDropEnumSQL will return drop enum sql
+func getDropEnumSQL(enumName string) (sql string) {
+	sql = fmt.Sprintf("DROP TYPE IF EXISTS %v;", enumName)
+	return
+}
+
 //getEnumDropValSQL will return enum drop value sql
 func getEnumDropValSQL(enumName string, value string) (sql string) {
 	sql = fmt.Sprintf("ALTER type %v DROP VALUE IF EXISTS '%v';", enumName, value)
@@ -186,10 +211,10 @@ func getEnumDropValSQL(enumName string, value string) (sql string) {
 }
 
 //Create Enum Query for given table
-func getEnumQuery(tx *pg.Tx, enumName string, enumValue []string) (
+func getEnumQuery(cat Catalog, enumName string, enumValue []string) (
 	query string, enumExists bool) {
 
-	if enumExists = dbEnumExists(tx, enumName); enumExists == false {
+	if enumExists = cat.EnumExists(enumName); enumExists == false {
 		query += fmt.Sprintf("CREATE type %v AS ENUM('%v'); ",
 			enumName, strings.Join(enumValue, "','"))
 	}
@@ -218,6 +243,40 @@ func (s *Shifter) getEnum(tableName, enumName string) (
 	return
 }
 
+//getEnumTypeName will return enum type name qualified by schema of the table
+//if table is schema qualified so that enum is created and looked up
+//in the table schema irrespective of the search path
+func (s *Shifter) getEnumTypeName(tableName, enumName string) string {
+	if schema, _ := util.SplitTableName(tableName); schema != "" &&
+		strings.Contains(enumName, ".") == false && s.isEnum(tableName, enumName) {
+		enumName = schema + "." + enumName
+	}
+	return enumName
+}
+
+//getEnumColSchema will return column schema with enum type qualified
+//by schema of the table which is used in column add/modify sql
+func (s *Shifter) getEnumColSchema(schema model.ColSchema) model.ColSchema {
+	if schema.DataType == userDefined {
+		schema.UdtName = s.getEnumTypeName(schema.TableName, schema.UdtName)
+	} else {
+		schema.DataType = s.getEnumTypeName(schema.TableName, schema.DataType)
+	}
+	return schema
+}
+
+//getEnumCreateTableSQL will qualify enum column types of create table sql
+//by schema of the table
+func (s *Shifter) getEnumCreateTableSQL(tableName, sql string) string {
+	for _, schema := range s.getStructSchema(tableName) {
+		if typeName := s.getEnumTypeName(tableName, schema.DataType); typeName != schema.DataType {
+			col := quoteIdent(schema.ColumnName) + " "
+			sql = strings.Replace(sql, col+schema.DataType, col+typeName, 1)
+		}
+	}
+	return sql
+}
+
 //isEnum will check given type is enum or not
 func (s *Shifter) isEnum(tableName, enumName string) (flag bool) {
 
@@ -253,22 +312,22 @@ func (s *Shifter) getEnumFromMethod(tableName string) (enum map[string][]string)
 }
 
 //getDBEnumValue enum values by enumType from database
-func getDBEnumValue(tx *pg.Tx, enumName string) (enumValue []string, err error) {
+//enum name can be schema qualified else it is resolved using search path
+func getDBEnumValue(tx Tx, enumName string) (enumValue []string, err error) {
 	query := `SELECT e.enumlabel as enum_value
 	  FROM pg_enum e
-	  JOIN pg_type t ON e.enumtypid = t.oid
-	  WHERE t.typname = ?;`
-	if _, err = tx.Query(&enumValue, query, enumName); err != nil {
+	  WHERE e.enumtypid = to_regtype(?);`
+	if err = tx.Query(&enumValue, query, enumName); err != nil {
 		err = getWrapError(enumName, "enum type", query, err)
 	}
 	return
 }
 
 //dbEnumExists : Check if Enum Type Exists in database
-func dbEnumExists(tx *pg.Tx, enumName string) (flag bool) {
+func dbEnumExists(tx Tx, enumName string) (flag bool) {
 	var num int
-	enumSQL := `SELECT 1 FROM pg_type WHERE typname = ?;`
-	if _, err := tx.Query(pg.Scan(&num), enumSQL, enumName); err == nil && num == 1 {
+	enumSQL := `SELECT 1 FROM pg_type WHERE oid = to_regtype(?);`
+	if err := tx.Query(&num, enumSQL, enumName); err == nil && num == 1 {
 		flag = true
 	}
 	return
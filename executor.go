@@ -0,0 +1,390 @@
+package shifter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+var errNoExecutor = errors.New("postgresql connection or executor is not set")
+
+//Result is the result of executed sql
+type Result interface {
+	RowsAffected() int
+}
+
+//Querier executes sql on database or transaction.
+//Queries use ? placeholders as in go-pg i.e. WHERE table_name = ? and pg.In() for list.
+//Query scans the rows in dest which can be pointer to slice of structs having sql tag,
+//struct, slice of scalar or scalar
+type Querier interface {
+	Exec(query string, params ...interface{}) (Result, error)
+	Query(dest interface{}, query string, params ...interface{}) error
+}
+
+//Executor executes sql on database and begins the transactions of shifter
+type Executor interface {
+	Querier
+	Begin() (Tx, error)
+}
+
+//Tx is the transaction begun by Executor
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+//Session is the dedicated connection used to execute sql outside transaction
+//so that session settings like lock_timeout apply only to the sql of shifter
+type Session interface {
+	Querier
+	Close() error
+}
+
+//Sessioner is the executor which can open a dedicated session.
+//If executor is not a Sessioner then sql outside transaction
+//is executed without lock and statement timeout
+type Sessioner interface {
+	Session() (Session, error)
+}
+
+//SetExecutor will set the executor used to execute sql instead of go-pg connection
+//i.e. NewSQLExecutor() for database/sql, NewPgxExecutor() for pgx or NewRecorder().
+//If executor is set then connection passed to shifter methods is not used and can be nil
+func (s *Shifter) SetExecutor(exec Executor) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executor = exec
+	return s
+}
+
+//getExecutor will return executor of shifter
+//if executor is not set then go-pg connection is used
+//queries are printed by executor in verbose mode
+func (s *Shifter) getExecutor(conn *pg.DB) (exec Executor) {
+	if exec = s.executor; exec == nil {
+		if conn == nil {
+			return errExecutor{}
+		}
+		exec = NewPgExecutor(conn)
+	}
+	return debugExecutor{Executor: exec, s: s}
+}
+
+//formatQuery will replace the ? placeholders by params using go-pg formatter
+//it is used by executors of other drivers to execute the same queries
+func formatQuery(query string, params ...interface{}) string {
+	var fmter orm.Formatter
+	return string(fmter.FormatQuery(nil, query, params...))
+}
+
+//pgExecutor is the executor of go-pg connection
+type pgExecutor struct {
+	db *pg.DB
+}
+
+//pgTx is the transaction of go-pg connection
+type pgTx struct {
+	tx *pg.Tx
+}
+
+//pgSession is the session of go-pg connection having single connection in pool
+type pgSession struct {
+	pgExecutor
+}
+
+//NewPgExecutor will return executor of go-pg connection
+func NewPgExecutor(conn *pg.DB) Executor {
+	return pgExecutor{db: conn}
+}
+
+//Exec will execute the sql
+func (e pgExecutor) Exec(query string, params ...interface{}) (Result, error) {
+	return e.db.Exec(query, params...)
+}
+
+//Query will execute the query and scan the rows in dest
+func (e pgExecutor) Query(dest interface{}, query string, params ...interface{}) (err error) {
+	_, err = e.db.Query(getPgModel(dest), query, params...)
+	return
+}
+
+//Begin will begin the transaction
+func (e pgExecutor) Begin() (Tx, error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return pgTx{tx: tx}, nil
+}
+
+//Session will open new go-pg connection having pool of single connection
+//so that all the sql of session are executed on same database connection
+func (e pgExecutor) Session() (Session, error) {
+	opt := *e.db.Options()
+	opt.PoolSize = 1
+	return pgSession{pgExecutor{db: pg.Connect(&opt)}}, nil
+}
+
+//Close will close the connection of session
+func (s pgSession) Close() error {
+	return s.db.Close()
+}
+
+//Exec will execute the sql in transaction
+func (t pgTx) Exec(query string, params ...interface{}) (Result, error) {
+	return t.tx.Exec(query, params...)
+}
+
+//Query will execute the query in transaction and scan the rows in dest
+func (t pgTx) Query(dest interface{}, query string, params ...interface{}) (err error) {
+	_, err = t.tx.Query(getPgModel(dest), query, params...)
+	return
+}
+
+//Commit will commit the transaction
+func (t pgTx) Commit() error {
+	return t.tx.Commit()
+}
+
+//Rollback will rollback the transaction
+func (t pgTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+//getPgModel will return go-pg model of dest
+//scalar is scanned using pg.Scan()
+func getPgModel(dest interface{}) interface{} {
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr {
+		if k := v.Elem().Kind(); k == reflect.Slice ||
+			(k == reflect.Struct && v.Elem().Type() != reflect.TypeOf(time.Time{})) {
+			return dest
+		}
+	}
+	return pg.Scan(dest)
+}
+
+//errExecutor is used if neither connection nor executor is set
+type errExecutor struct{}
+
+//Exec will return executor not set error
+func (errExecutor) Exec(query string, params ...interface{}) (Result, error) {
+	return nil, errNoExecutor
+}
+
+//Query will return executor not set error
+func (errExecutor) Query(dest interface{}, query string, params ...interface{}) error {
+	return errNoExecutor
+}
+
+//Begin will return executor not set error
+func (errExecutor) Begin() (Tx, error) {
+	return nil, errNoExecutor
+}
+
+//debugExecutor prints the queries executed by shifter in verbose mode
+type debugExecutor struct {
+	Executor
+	s *Shifter
+}
+
+//debugTx prints the queries executed in transaction in verbose mode
+type debugTx struct {
+	Tx
+	s *Shifter
+}
+
+//debugSession prints the queries executed in session in verbose mode
+type debugSession struct {
+	Session
+	s *Shifter
+}
+
+//Exec will execute the sql and print it in verbose mode
+func (d debugExecutor) Exec(query string, params ...interface{}) (res Result, err error) {
+	start := time.Now()
+	res, err = d.Executor.Exec(query, params...)
+	d.s.debug(start, err, query, params...)
+	return
+}
+
+//Query will execute the query and print it in verbose mode
+func (d debugExecutor) Query(dest interface{}, query string, params ...interface{}) (err error) {
+	start := time.Now()
+	err = d.Executor.Query(dest, query, params...)
+	d.s.debug(start, err, query, params...)
+	return
+}
+
+//Begin will begin the transaction which prints the queries in verbose mode
+func (d debugExecutor) Begin() (Tx, error) {
+	tx, err := d.Executor.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return debugTx{Tx: tx, s: d.s}, nil
+}
+
+//Exec will execute the sql in transaction and print it in verbose mode
+func (d debugTx) Exec(query string, params ...interface{}) (res Result, err error) {
+	start := time.Now()
+	res, err = d.Tx.Exec(query, params...)
+	d.s.debug(start, err, query, params...)
+	return
+}
+
+//Query will execute the query in transaction and print it in verbose mode
+func (d debugTx) Query(dest interface{}, query string, params ...interface{}) (err error) {
+	start := time.Now()
+	err = d.Tx.Query(dest, query, params...)
+	d.s.debug(start, err, query, params...)
+	return
+}
+
+//Exec will execute the sql in session and print it in verbose mode
+func (d debugSession) Exec(query string, params ...interface{}) (res Result, err error) {
+	start := time.Now()
+	res, err = d.Session.Exec(query, params...)
+	d.s.debug(start, err, query, params...)
+	return
+}
+
+//Query will execute the query in session and print it in verbose mode
+func (d debugSession) Query(dest interface{}, query string, params ...interface{}) (err error) {
+	start := time.Now()
+	err = d.Session.Query(dest, query, params...)
+	d.s.debug(start, err, query, params...)
+	return
+}
+
+//getSession will open session of the executor which prints the queries in verbose mode
+//nil session is returned if executor is not a Sessioner
+func getSession(conn Executor) (sess Session, err error) {
+	exec := conn
+	d, isDebug := conn.(debugExecutor)
+	if isDebug {
+		exec = d.Executor
+	}
+	if sessioner, ok := exec.(Sessioner); ok {
+		if sess, err = sessioner.Session(); err == nil && isDebug {
+			sess = debugSession{Session: sess, s: d.s}
+		}
+	}
+	return
+}
+
+//debug will print the executed query if sql logging is enabled
+func (s *Shifter) debug(start time.Time, err error, query string, params ...interface{}) {
+	if s.logSQL {
+		var queryError string
+		if err != nil {
+			queryError = "\nQUERY ERROR: " + err.Error()
+		}
+		fmt.Println("----DEBUGGER----")
+		fmt.Printf("\nQuery Execution Taken: %s\n%s%s\n\n",
+			time.Since(start), formatQuery(query, params...), queryError)
+	}
+}
+
+//rowScanner reads the rows of query result of sql driver
+type rowScanner interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+//scanRows will scan the rows in dest by matching the columns with sql tag of struct fields
+//used by executors of other drivers to scan the rows like go-pg
+func scanRows(dest interface{}, columns []string, rows rowScanner) (err error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("scan destination %T is not a pointer", dest)
+	}
+	v = v.Elem()
+	isSlice := v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8
+	for rows.Next() {
+		var values []interface{}
+		if values, err = rows.Values(); err != nil {
+			break
+		}
+		if isSlice {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err = setRow(elem, columns, values); err != nil {
+				break
+			}
+			v.Set(reflect.Append(v, elem))
+		} else if err = setRow(v, columns, values); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	return
+}
+
+//setRow will set the column values in struct fields having same sql tag
+//or in scalar if it is not a struct
+func setRow(v reflect.Value, columns []string, values []interface{}) (err error) {
+	if v.Kind() != reflect.Struct || v.Type() == reflect.TypeOf(time.Time{}) {
+		if len(values) > 0 {
+			err = setValue(v, values[0])
+		}
+		return
+	}
+	fields := make(map[string]int)
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("sql"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(v.Type().Field(i).Name)
+		}
+		fields[name] = i
+	}
+	for i, col := range columns {
+		if idx, exists := fields[col]; exists && i < len(values) {
+			if err = setValue(v.Field(idx), values[i]); err != nil {
+				err = fmt.Errorf("column %v: %v", col, err)
+				break
+			}
+		}
+	}
+	return
+}
+
+//setValue will set the column value in field
+//null is set as zero value
+func setValue(field reflect.Value, value interface{}) (err error) {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+	str := fmt.Sprint(value)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(str)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(str, 10, 64); err == nil {
+			field.SetInt(n)
+		}
+	case reflect.Bool:
+		field.SetBool(str == "t" || str == "true")
+	default:
+		if rv := reflect.ValueOf(value); rv.Type().ConvertibleTo(field.Type()) {
+			field.Set(rv.Convert(field.Type()))
+		} else {
+			err = fmt.Errorf("can't scan %T in %v", value, field.Type())
+		}
+	}
+	return
+}
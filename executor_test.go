@@ -0,0 +1,98 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+//testRows is the query result used to test row scanning
+type testRows struct {
+	rows [][]interface{}
+	idx  int
+}
+
+func (r *testRows) Next() bool {
+	r.idx++
+	return r.idx <= len(r.rows)
+}
+
+func (r *testRows) Values() ([]interface{}, error) {
+	return r.rows[r.idx-1], nil
+}
+
+func (r *testRows) Err() error {
+	return nil
+}
+
+func TestScanRows(t *testing.T) {
+	assert := assert.New(t)
+	var columns []model.ColSchema
+	err := scanRows(&columns, []string{"column_name", "is_nullable", "character_maximum_length",
+		"position", "column_default"}, &testRows{rows: [][]interface{}{
+		{[]byte("user_id"), "NO", nil, int64(1), nil},
+		{"name", "YES", int64(255), int32(2), "'none'::text"},
+	}})
+	assert.NoError(err)
+	assert.Equal([]model.ColSchema{
+		{ColumnName: "user_id", IsNullable: "NO", Position: 1},
+		{ColumnName: "name", IsNullable: "YES", CharMaxLen: "255", Position: 2,
+			ColumnDefault: "'none'::text"},
+	}, columns)
+
+	var count int
+	assert.NoError(scanRows(&count, []string{"count"}, &testRows{rows: [][]interface{}{{int64(3)}}}))
+	assert.Equal(3, count)
+
+	var locked bool
+	assert.NoError(scanRows(&locked, []string{"locked"}, &testRows{rows: [][]interface{}{{"t"}}}))
+	assert.True(locked)
+
+	var names []string
+	assert.NoError(scanRows(&names, []string{"name"},
+		&testRows{rows: [][]interface{}{{"a"}, {[]byte("b")}}}))
+	assert.Equal([]string{"a", "b"}, names)
+
+	assert.Error(scanRows(count, []string{"count"}, &testRows{}))
+}
+
+func TestFormatQuery(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("SELECT 1 FROM pg_tables WHERE tablename = 'test_user'",
+		formatQuery("SELECT 1 FROM pg_tables WHERE tablename = ?", "test_user"))
+	assert.Equal("SELECT count(*) FROM pg_proc WHERE proname IN ('random','now')",
+		formatQuery("SELECT count(*) FROM pg_proc WHERE proname IN (?)", pg.In([]string{"random", "now"})))
+
+	var count int
+	var names []string
+	assert.Equal(&names, getPgModel(&names))
+	assert.NotEqual(&count, getPgModel(&count))
+}
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRecorder(nil)
+	s := NewShifter().SetExecutor(r)
+	tx, err := s.getExecutor(nil).Begin()
+	assert.NoError(err)
+	_, err = tx.Exec("ALTER TABLE test_user DROP COLUMN IF EXISTS name")
+	assert.NoError(err)
+	assert.NoError(tx.Rollback())
+	assert.Empty(r.Script())
+
+	tx, _ = r.Begin()
+	tx.Exec("ALTER TABLE test_user ADD COLUMN ? text;\n", pg.Q("name"))
+	assert.NoError(tx.Commit())
+	r.Exec("CREATE INDEX CONCURRENTLY idx_user_name ON test_user (name)")
+	assert.Equal("BEGIN;\nALTER TABLE test_user ADD COLUMN name text;\nCOMMIT;\n"+
+		"CREATE INDEX CONCURRENTLY idx_user_name ON test_user (name);\n", r.Script())
+	assert.Equal(errRecordOnly, tx.Query(&[]string{}, "SELECT 1"))
+	r.Reset()
+	assert.Empty(r.Script())
+
+	//shifter without connection or executor
+	_, err = NewShifter().getExecutor(nil).Begin()
+	assert.Equal(errNoExecutor, err)
+}
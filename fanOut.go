@@ -0,0 +1,257 @@
+package shifter
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//SchemaSelector selects the schemas on which AlterAllSchemas alters tables
+type SchemaSelector func(schema string) bool
+
+//SchemaPrefix will return selector of schemas starting with prefix i.e. tenant_
+func SchemaPrefix(prefix string) SchemaSelector {
+	return func(schema string) bool {
+		return strings.HasPrefix(schema, prefix)
+	}
+}
+
+//SchemaList will return selector of the given schemas
+func SchemaList(schemas ...string) SchemaSelector {
+	list := make(map[string]struct{})
+	for _, schema := range schemas {
+		list[schema] = struct{}{}
+	}
+	return func(schema string) bool {
+		_, exists := list[schema]
+		return exists
+	}
+}
+
+//FanOut is the option of AlterAllSchemas
+type FanOut struct {
+	//Parallel is the number of schemas altered at a time. Default is 1
+	//with parallel alter prompter must be safe for concurrent use
+	Parallel int
+	//ContinueOnError will alter remaining schemas even if a schema fails
+	ContinueOnError bool
+	//SkipPrompt will execute sql without prompt for confirmation
+	SkipPrompt bool
+}
+
+//SchemaResult is the alter result of a schema
+type SchemaResult struct {
+	Schema string
+	//Applied are the changes committed in the schema
+	Applied []Change
+	//Skipped is true if schema is not altered because other schema failed
+	Skipped  bool
+	Err      error
+	Duration time.Duration
+}
+
+//SchemaReport is the per schema result of AlterAllSchemas in schema order
+type SchemaReport []SchemaResult
+
+//Failed will return the schemas failed to alter
+func (r SchemaReport) Failed() (schemas []string) {
+	for _, result := range r {
+		if result.Err != nil {
+			schemas = append(schemas, result.Schema)
+		}
+	}
+	return
+}
+
+//Skipped will return the schemas not altered because other schema failed
+func (r SchemaReport) Skipped() (schemas []string) {
+	for _, result := range r {
+		if result.Skipped {
+			schemas = append(schemas, result.Schema)
+		}
+	}
+	return
+}
+
+//String will return the report in readable form, one line per schema
+func (r SchemaReport) String() string {
+	var b strings.Builder
+	for _, result := range r {
+		status := "ok"
+		if result.Skipped {
+			status = "skipped"
+		} else if result.Err != nil {
+			status = "failed: " + result.Err.Error()
+		}
+		fmt.Fprintf(&b, "%v: %v changes in %v %v\n",
+			result.Schema, len(result.Applied), result.Duration, status)
+	}
+	return b.String()
+}
+
+//SchemaError is returned by AlterAllSchemas if any schema failed to alter
+type SchemaError struct {
+	Report SchemaReport
+}
+
+func (e *SchemaError) Error() string {
+	failed := e.Report.Failed()
+	return fmt.Sprintf("alter failed in %v of %v schemas: %v",
+		len(failed), len(e.Report), strings.Join(failed, ", "))
+}
+
+// AlterAllSchemas will alter all tables in every schema matched by selector.
+// It is meant for one schema per tenant having identical tables.
+//
+// Parameters
+//  conn: postgresql connection
+//  selector: selects the schemas to alter i.e. SchemaPrefix("tenant_")
+//  opt: parallelism, continue on error and prompt option
+// Each schema is altered in its own transaction with search path set to the schema.
+// Tables which are schema qualified in struct sql tag are not altered.
+// If a schema fails then schemas not started yet are skipped
+// unless ContinueOnError is set. Changes applied in schemas are in the report
+// and are not part of AppliedChanges() as their sql is not schema qualified.
+func (s *Shifter) AlterAllSchemas(conn *pg.DB, selector SchemaSelector, opt ...FanOut) (
+	report SchemaReport, err error) {
+
+	s.lock()
+	defer s.unlock()
+
+	var schemas []string
+	fanOut := getFanOut(opt)
+	db := s.getExecutor(conn)
+	if err = s.advisoryLock(db); err == nil {
+		if schemas, err = getDBSchema(db, selector); err == nil && s.journal {
+			//journal table is created once as schemas are altered in parallel
+			err = createJournalTable(db)
+		}
+	}
+	if err == nil {
+		var (
+			wg     sync.WaitGroup
+			failed int32
+		)
+		report = make(SchemaReport, len(schemas))
+		jobs := make(chan int)
+		for i := 0; i < fanOut.Parallel; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for idx := range jobs {
+					if report[idx] = s.alterSchema(db, schemas[idx],
+						fanOut.SkipPrompt); report[idx].Err != nil {
+						atomic.StoreInt32(&failed, 1)
+					}
+				}
+			}()
+		}
+		for idx, schema := range schemas {
+			if fanOut.ContinueOnError == false && atomic.LoadInt32(&failed) == 1 {
+				report[idx] = SchemaResult{Schema: schema, Skipped: true}
+			} else {
+				jobs <- idx
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		if len(report.Failed()) > 0 {
+			err = &SchemaError{Report: report}
+		}
+	}
+	return
+}
+
+//getFanOut will return fan out option with default values
+func getFanOut(opt []FanOut) (fanOut FanOut) {
+	if len(opt) > 0 {
+		fanOut = opt[0]
+	}
+	if fanOut.Parallel < 1 {
+		fanOut.Parallel = 1
+	}
+	return
+}
+
+//alterSchema will alter all tables of the schema using a shifter of the schema
+func (s *Shifter) alterSchema(conn Executor, schema string, skipPrompt bool) (
+	result SchemaResult) {
+
+	start := time.Now()
+	schemaShifter := s.forSchema(schema)
+	schemaShifter.lock()
+	schemaShifter.run.journalCreated = s.journal
+	result.Err = schemaShifter.alterAllTable(conn, skipPrompt)
+	schemaShifter.unlock()
+	result.Schema = schema
+	result.Applied = schemaShifter.AppliedChanges()
+	result.Duration = time.Since(start)
+	return
+}
+
+//forSchema will return copy of shifter configuration for the schema
+//having only the tables which are not schema qualified
+func (s *Shifter) forSchema(schema string) *Shifter {
+	schemaShifter := &Shifter{
+		table:       make(map[string]interface{}),
+		enumList:    s.enumList,
+		verbose:     s.verbose,
+		logPath:     s.logPath,
+		journal:     s.journal,
+		prompter:    s.prompter,
+		policy:      s.policy,
+		schema:      schema,
+		online:      s.online,
+		batchSize:   s.batchSize,
+		concurrent:  s.concurrent,
+		lockTimeout: s.lockTimeout,
+		stmtTimeout: s.stmtTimeout,
+		retry:       s.retry,
+		executor:    s.executor,
+		ctx:         s.ctx,
+		run:         newRunState(),
+	}
+	for tableName, model := range s.table {
+		if tSchema, _ := util.SplitTableName(tableName); tSchema == "" {
+			schemaShifter.table[tableName] = model
+		}
+	}
+	return schemaShifter
+}
+
+//alterAllTable will alter all tables in a single transaction
+func (s *Shifter) alterAllTable(conn Executor, skipPrompt bool) (err error) {
+	err = s.runTx(conn, func(tx Tx) (err error) {
+		for tableName := range s.table {
+			if err = s.alterTable(tx, tableName, skipPrompt); err != nil {
+				break
+			}
+		}
+		return
+	})
+	return
+}
+
+//getDBSchema will return database schemas matched by selector in sorted order
+//system schemas are not considered
+func getDBSchema(conn Executor, selector SchemaSelector) (schemas []string, err error) {
+	var dbSchema []string
+	query := `SELECT nspname FROM pg_namespace
+	WHERE nspname NOT LIKE 'pg\_%' AND nspname <> 'information_schema'
+	ORDER BY nspname;`
+	if err = conn.Query(&dbSchema, query); err == nil {
+		for _, schema := range dbSchema {
+			if selector == nil || selector(schema) {
+				schemas = append(schemas, schema)
+			}
+		}
+	} else {
+		err = getWrapError("pg_namespace", "schema list", query, err)
+	}
+	return
+}
@@ -0,0 +1,67 @@
+package shifter
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaSelector(t *testing.T) {
+	assert := assert.New(t)
+	prefix := SchemaPrefix("tenant_")
+	assert.True(prefix("tenant_1"))
+	assert.False(prefix("public"))
+	list := SchemaList("tenant_1", "tenant_3")
+	assert.True(list("tenant_3"))
+	assert.False(list("tenant_2"))
+}
+
+func TestForSchema(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&db.TestAddress{}, &localInvoice2{}).Verbose(true)
+	schemaShifter := s.forSchema("tenant_1")
+	assert.Equal("tenant_1", schemaShifter.schema)
+	assert.True(schemaShifter.verbose)
+	assert.Equal([]string{"test_address"}, schemaShifter.TableNames())
+	assert.Equal(1, getFanOut(nil).Parallel)
+	assert.Equal(4, getFanOut([]FanOut{{Parallel: 4}}).Parallel)
+}
+
+func TestSchemaReport(t *testing.T) {
+	assert := assert.New(t)
+	report := SchemaReport{
+		{Schema: "tenant_1"},
+		{Schema: "tenant_2", Err: errors.New("lock timeout")},
+		{Schema: "tenant_3", Skipped: true},
+	}
+	assert.Equal([]string{"tenant_2"}, report.Failed())
+	assert.Equal([]string{"tenant_3"}, report.Skipped())
+	assert.Contains(report.String(), "tenant_2: 0 changes in 0s failed: lock timeout")
+	err := &SchemaError{Report: report}
+	assert.Equal("alter failed in 1 of 3 schemas: tenant_2", err.Error())
+}
+
+func TestSchemaJournalAndLog(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "shifter")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s := NewShifter(&db.TestAddress{}).Journal(true).SetLogPath(dir)
+	schemaShifter := s.forSchema("tenant_1")
+	schemaShifter.addJournalLog(newChange(AddColumnChange, "test_address", "city", ""), 0, nil)
+	if assert.Len(schemaShifter.run.journalLog, 1) {
+		assert.Equal("tenant_1", schemaShifter.run.journalLog[0].Schema)
+		assert.Equal("test_address", schemaShifter.run.journalLog[0].TableName)
+	}
+
+	//struct log of each schema is in its own dir
+	logDir, err := schemaShifter.makeStructLogDir("TestAddress")
+	assert.NoError(err)
+	assert.Equal(filepath.Join(dir, "tenant_1", "TestAddress"), filepath.Clean(logDir))
+}
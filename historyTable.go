@@ -3,16 +3,15 @@ package shifter
 import (
 	"fmt"
 
-	"github.com/go-pg/pg"
 	"github.com/mayur-tolexo/flaw"
 	"github.com/mayur-tolexo/pg-shifter/util"
 )
 
 //Create history table
-func (s *Shifter) createHistory(tx *pg.Tx, tableName string) (err error) {
+func (s *Shifter) createHistory(tx Tx, tableName string) (err error) {
 	if s.isSkip(tableName) == false {
 		historyTable := util.GetHistoryTableName(tableName)
-		if tableExists := tableExists(tx, historyTable); tableExists == false {
+		if tableExists := s.catalog(tx).TableExists(historyTable); tableExists == false {
 			if err = s.execHistoryTable(tx, tableName, historyTable); err == nil {
 				if err = s.dropHistoryConstraint(tx, historyTable); err == nil {
 					err = s.createTrigger(tx, tableName)
@@ -24,21 +23,21 @@ func (s *Shifter) createHistory(tx *pg.Tx, tableName string) (err error) {
 }
 
 //dropHistory will drop history table
-func (s *Shifter) dropHistory(tx *pg.Tx, tableName string, cascade bool) (err error) {
+func (s *Shifter) dropHistory(tx Tx, tableName string, cascade, skipPrompt bool) (err error) {
 	historyTable := util.GetHistoryTableName(tableName)
-	if tableExists := tableExists(tx, historyTable); tableExists == true {
-		err = execTableDrop(tx, historyTable, cascade)
+	if tableExists := s.catalog(tx).TableExists(historyTable); tableExists == true {
+		_, err = s.execTableDrop(tx, historyTable, cascade, skipPrompt)
 	}
 	return
 }
 
 //dropHistoryConstraint will drop history table constraints
-func (s *Shifter) dropHistoryConstraint(tx *pg.Tx, historyTable string) (err error) {
+func (s *Shifter) dropHistoryConstraint(tx Tx, historyTable string) (err error) {
 	sql := `
 		ALTER TABLE %v DROP COLUMN IF EXISTS updated_at;
 		ALTER TABLE %v ADD COLUMN IF NOT EXISTS created_at timetz DEFAULT now();`
 	sql = fmt.Sprintf(sql, historyTable, historyTable)
-	if _, err = tx.Exec(sql); err != nil {
+	if err = s.exec(tx, newChange(CreateHistoryChange, historyTable, "", sql)); err != nil {
 		msg := `History Table Error: ` + historyTable + `
 		SQL:` + sql
 		err = flaw.ExecError(err, msg)
@@ -48,7 +47,7 @@ func (s *Shifter) dropHistoryConstraint(tx *pg.Tx, historyTable string) (err err
 }
 
 //execHistoryTable will execute history table creation
-func (s *Shifter) execHistoryTable(tx *pg.Tx, tableName, historyTable string) (err error) {
+func (s *Shifter) execHistoryTable(tx Tx, tableName, historyTable string) (err error) {
 
 	sql := `
 	CREATE TABLE %v (
@@ -58,7 +57,9 @@ func (s *Shifter) execHistoryTable(tx *pg.Tx, tableName, historyTable string) (e
 	);
 	`
 	sql = fmt.Sprintf(sql, historyTable, tableName)
-	if _, err = tx.Exec(fmt.Sprintf(sql)); err != nil {
+	c := newChange(CreateHistoryChange, historyTable, "", sql)
+	c.DownSQL = getDropTableSQL(historyTable)
+	if err = s.exec(tx, c); err != nil {
 		msg := fmt.Sprintf("Table: %v", tableName)
 		err = flaw.ExecError(err, msg)
 		fmt.Println("History Error:", msg, err)
@@ -3,9 +3,9 @@ package shifter
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/go-pg/pg"
 	"github.com/mayur-tolexo/pg-shifter/model"
 	"github.com/mayur-tolexo/pg-shifter/util"
 )
@@ -21,29 +21,146 @@ const (
 )
 
 //Create index of given table
-func (s *Shifter) createIndex(tx *pg.Tx, tableName string, skipPrompt bool) (err error) {
-	var indexSQL string
-	for index, idxType := range s.getIndexFromMethod(tableName) {
-		indexSQL += getIndexQuery(tableName, idxType, index)
+func (s *Shifter) createIndex(tx Tx, tableName string, skipPrompt bool) (err error) {
+	sIdx := s.getStructIndex(tableName)
+	//invalid index of existing table is dropped so that it is built again
+	if _, created := s.run.newTables[tableName]; created == false && len(sIdx) > 0 {
+		_, err = s.dropInvalidIndex(tx, tableName, skipPrompt)
 	}
-	if indexSQL != "" {
-		choice := util.GetChoice("INDEX:\n"+indexSQL, skipPrompt)
-		if choice == util.Yes {
-			if _, err = tx.Exec(indexSQL); err != nil {
-				err = getWrapError(tableName, "create index", indexSQL, err)
+	for _, idxName := range getSortedIndexName(sIdx) {
+		if err != nil {
+			break
+		}
+		_, err = s.addIndex(tx, tableName, sIdx[idxName], skipPrompt)
+	}
+	return
+}
+
+//modifyIndex will modify index by comparing table and struct index.
+//index created by shifter which is not in struct will be dropped,
+//modified index will be recreated and new index will be created.
+//Index not created by shifter i.e. created by hand or post create sql is kept.
+//Invalid indexes are dropped by caller before it
+func (s *Shifter) modifyIndex(tx Tx, tableName string, skipPrompt bool) (
+	tIdx []model.Index, isAlter bool, err error) {
+
+	sIdx := s.getStructIndex(tableName)
+	if tIdx, err = s.catalog(tx).Index(tableName); err == nil {
+		for _, curTableIdx := range tIdx {
+			var curAlter bool
+			if curStructIdx, exists := sIdx[curTableIdx.IdxName]; exists == false {
+				if isShifterIndex(tableName, curTableIdx.IdxName) {
+					curAlter, err = s.dropIndex(tx, tableName, curTableIdx, skipPrompt)
+				}
+			} else {
+				delete(sIdx, curTableIdx.IdxName)
+				if isSameIndex(curTableIdx, curStructIdx) == false {
+					curAlter, err = s.recreateIndex(tx, tableName, curTableIdx, curStructIdx, skipPrompt)
+				}
+			}
+			if err != nil {
+				break
+			}
+			isAlter = isAlter || curAlter
+		}
+		if err == nil {
+			//creating index which exists in struct but not in table
+			for _, idxName := range getSortedIndexName(sIdx) {
+				var curAlter bool
+				if curAlter, err = s.addIndex(tx, tableName, sIdx[idxName], skipPrompt); err != nil {
+					break
+				}
+				isAlter = isAlter || curAlter
 			}
 		}
 	}
 	return
 }
 
+//recreateIndex will drop the index and create it again
+func (s *Shifter) recreateIndex(tx Tx, tableName string, tIdx, sIdx model.Index,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	if isAlter, err = s.dropIndex(tx, tableName, tIdx, skipPrompt); err == nil {
+		var curAlter bool
+		curAlter, err = s.addIndex(tx, tableName, sIdx, skipPrompt)
+		isAlter = isAlter || curAlter
+	}
+	return
+}
+
+//addIndex will create index on table
+func (s *Shifter) addIndex(tx Tx, tableName string, idx model.Index,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	idxName := getIndexName(tableName, idx.Columns)
+	sql := getIndexQueryByName(idxName, tableName, idx.IType, idx.Columns)
+	c := newChange(CreateIndexChange, tableName, idx.Columns, sql)
+	c.DownSQL = getDropIndexSQL(tableName, idxName)
+	if s.isConcurrent(tableName) {
+		c.SQL, c.NoTx = s.getConcurrentIndexSQL(idxName, tableName, idx.IType, idx.Columns), true
+	}
+	isAlter, err = s.execByChoice(tx, c, skipPrompt)
+	return
+}
+
+//dropIndex will drop index from table
+func (s *Shifter) dropIndex(tx Tx, tableName string, idx model.Index,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	sql := getDropIndexSQL(tableName, idx.IdxName)
+	c := newChange(DropIndexChange, tableName, "", sql)
+	c.DownSQL = getIndexQueryByName(idx.IdxName, tableName, idx.IType, idx.Columns)
+	if s.isConcurrent(tableName) {
+		c.SQL, c.NoTx = s.getConcurrentDropIndexSQL(tableName, idx.IdxName), true
+	}
+	isAlter, err = s.execByChoice(tx, c, skipPrompt)
+	return
+}
+
+//isSameIndex will check table and struct index have same columns and index type
+func isSameIndex(tIdx, sIdx model.Index) bool {
+	tType := strings.Replace(tIdx.IType, "-", "", -1)
+	sType := strings.Replace(getIndexType(sIdx.IType), "-", "", -1)
+	return getTrimmedColumns(tIdx.Columns) == getTrimmedColumns(sIdx.Columns) &&
+		tType == sType
+}
+
+//getIndexName will return index name by tablename and table columns
+func getIndexName(tableName string, column string) (idxName string) {
+	idxName = fmt.Sprintf("idx_%v_%v", util.GetBareName(tableName),
+		strings.Replace(getTrimmedColumns(column), ",", "_", -1))
+	idxName = util.GetUniqueStrByLen(idxName, 64)
+	return
+}
+
+//isShifterIndex will check index name is created by shifter using getIndexName
+func isShifterIndex(tableName, idxName string) bool {
+	return strings.HasPrefix(idxName, "idx_"+util.GetBareName(tableName)+"_")
+}
+
 //Get index query by tablename and table columns
 func getIndexQuery(tableName string, indexDS string, column string) (uniqueKeyQuery string) {
+	constraintName := getIndexName(tableName, column)
+	return getIndexQueryByName(constraintName, tableName, indexDS, column)
+}
+
+//getIndexQueryByName will return create index query with given index name
+func getIndexQueryByName(idxName, tableName, indexDS, column string) (indexQuery string) {
 	indexDS = getIndexType(indexDS)
-	constraintName := fmt.Sprintf("idx_%v_%v", tableName, strings.Replace(strings.Replace(column, " ", "", -1), ",", "_", -1))
-	constraintName = util.GetStrByLen(constraintName, 64)
-	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %v ON %v USING %v (%v);\n",
-		constraintName, tableName, indexDS, column)
+	indexQuery = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %v ON %v USING %v (%v);\n",
+		idxName, tableName, indexDS, column)
+	return
+}
+
+//getDropIndexSQL will return drop index sql
+//index is in the schema of the table so index name is qualified by table schema
+func getDropIndexSQL(tableName, idxName string) (sql string) {
+	if schema, _ := util.SplitTableName(tableName); schema != "" {
+		idxName = schema + "." + idxName
+	}
+	sql = fmt.Sprintf("DROP INDEX IF EXISTS %v;\n", idxName)
+	return
 }
 
 //getIndexType will return index type to use
@@ -79,8 +196,31 @@ func (s *Shifter) getIndexFromMethod(tableName string) (idx map[string]string) {
 	return
 }
 
+//getStructIndex will return struct index by index name
+func (s *Shifter) getStructIndex(tableName string) (idx map[string]model.Index) {
+	idx = make(map[string]model.Index)
+	for column, idxType := range s.getIndexFromMethod(tableName) {
+		idxName := getIndexName(tableName, column)
+		idx[idxName] = model.Index{
+			IdxName: idxName,
+			IType:   idxType,
+			Columns: column,
+		}
+	}
+	return
+}
+
+//getSortedIndexName will return index names in sorted order
+func getSortedIndexName(idx map[string]model.Index) (idxName []string) {
+	for k := range idx {
+		idxName = append(idxName, k)
+	}
+	sort.Strings(idxName)
+	return
+}
+
 //getDBIndex : Get index of table from database
-func getDBIndex(tx *pg.Tx, tableName string) (idx []model.Index, err error) {
+func getDBIndex(tx Tx, tableName string) (idx []model.Index, err error) {
 	query := `
 	with idx as (
 		select
@@ -97,10 +237,12 @@ func getDBIndex(tx *pg.Tx, tableName string) (idx []model.Index, err error) {
 		    join unnest(ix.indkey::int[]) as colNo on true 
 		    join information_schema.columns as c 
 			on c.ordinal_position = colNo and c.table_name = t.relname
+			and c.table_schema = t.relnamespace::regnamespace::text
 		where
 		    t.relkind = 'r'
 		    and ix.indisunique = false
-		    and t.relname = ?
+		    and ix.indisvalid = true
+		    and t.oid = ?::regclass::oid
 		   order by i.relname, position
 	)
 	select index_name 
@@ -108,6 +250,6 @@ func getDBIndex(tx *pg.Tx, tableName string) (idx []model.Index, err error) {
 	, string_agg(column_name,',') as col
 	from idx
 	group by index_name;`
-	_, err = tx.Query(&idx, query, tableName)
+	err = tx.Query(&idx, query, tableName)
 	return
 }
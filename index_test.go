@@ -0,0 +1,45 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSameIndex(t *testing.T) {
+	assert := assert.New(t)
+	tIdx := model.Index{IdxName: "idx_test_address_address_id_status", IType: BtreeIndex, Columns: "address_id,status"}
+
+	assert.True(isSameIndex(tIdx, model.Index{Columns: "address_id, status"}))
+	assert.False(isSameIndex(tIdx, model.Index{Columns: "status,address_id"}))
+	assert.False(isSameIndex(tIdx, model.Index{Columns: "address_id,status", IType: GinIndex}))
+	assert.Equal(tIdx.IdxName, getIndexName("test_address", "address_id, status"))
+}
+
+func TestModifyIndex(t *testing.T) {
+	assert := assert.New(t)
+	//index name longer than postgresql limit is unique and within limit
+	long := getIndexName("test_address", "address_line_one, address_line_two, address_city_name")
+	assert.True(len(long) < 64)
+	assert.NotEqual(long, getIndexName("test_address", "address_line_one, address_line_two, address_city_code"))
+
+	s := NewShifter(&db.TestAddress{})
+	s.run.dryRun = true
+	s.run.catalog = Snapshot{Tables: map[string]TableSnapshot{"test_address": {
+		Indexes: []model.Index{
+			{IdxName: "idx_test_address_status", IType: BtreeIndex, Columns: "status"},
+			{IdxName: "idx_test_address_address_id_status", IType: BtreeIndex, Columns: "address_id,status"},
+			{IdxName: "idx_test_address_city", IType: BtreeIndex, Columns: "city"},
+			{IdxName: "test_address_lower_email", IType: BtreeIndex, Columns: "lower(email)"},
+		}}}}
+	_, isAlter, err := s.modifyIndex(nil, "test_address", true)
+	assert.NoError(err)
+	assert.True(isAlter)
+	//only index created by shifter is dropped
+	if assert.Len(s.run.changes, 1) {
+		assert.Equal(DropIndexChange, s.run.changes[0].Kind)
+		assert.Contains(s.run.changes[0].SQL, "idx_test_address_city")
+	}
+}
@@ -0,0 +1,152 @@
+package shifter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//JournalTable is the table name in which executed sql are recorded
+const JournalTable = "shifter_migrations"
+
+//journal outcome
+const (
+	successOutcome    = "success"
+	failedOutcome     = "failed"
+	rolledBackOutcome = "rolled back"
+)
+
+//Journal will enable recording of every executed sql in shifter_migrations table
+func (s *Shifter) Journal(enable bool) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal = enable
+	return s
+}
+
+// History will return the migration journal of the given table in executed order.
+// If table name is empty then journal of all tables will be returned.
+//
+// Parameters
+//  conn: postgresql connection
+//  tableName: table name, schema qualified name i.e. tenant_1.test_user returns journal of the schema
+// journal is recorded only if it is enabled using Journal()
+func (s *Shifter) History(conn *pg.DB, tableName string) (
+	logs []model.MigrationLog, err error) {
+
+	query := `SELECT id, table_name, schema_name, operation, sql, down_sql, checksum, duration_ms,
+	db_user, outcome, error, applied_at FROM ` + JournalTable
+	params := []interface{}{}
+	if schema, bareName := util.SplitTableName(tableName); schema != "" {
+		query += ` WHERE (table_name = ? OR (schema_name = ? AND table_name = ?))`
+		params = append(params, tableName, schema, bareName)
+	} else if tableName != "" {
+		query += ` WHERE table_name = ?`
+		params = append(params, tableName)
+	}
+	query += ` ORDER BY id;`
+	if err = s.getExecutor(conn).Query(&logs, query, params...); err != nil {
+		err = getWrapError(tableName, "migration history", query, err)
+	}
+	return
+}
+
+//addJournalLog will add executed change in pending journal logs
+func (s *Shifter) addJournalLog(c Change, duration time.Duration, execErr error) {
+	if s.journal {
+		log := model.MigrationLog{
+			TableName: c.Table,
+			Schema:    s.getTableSchemaName(c.Table),
+			Operation: string(c.Kind),
+			SQL:       c.SQL,
+			DownSQL:   c.DownSQL,
+			Checksum:  s.getSchemaChecksum(c.Table),
+			Duration:  duration.Nanoseconds() / int64(time.Millisecond),
+			Outcome:   successOutcome,
+		}
+		if execErr != nil {
+			log.Outcome = failedOutcome
+			log.Error = execErr.Error()
+		}
+		s.run.journalLog = append(s.run.journalLog, log)
+	}
+}
+
+//writeJournal will write pending journal logs in journal table
+//if transaction is not committed then successful logs are marked as rolled back.
+//Schema of table not qualified by shifter schema is the current schema of connection.
+//Pending logs are discarded and error is returned if journal can't be written
+func (s *Shifter) writeJournal(conn Executor, committed bool) (err error) {
+	if len(s.run.journalLog) > 0 {
+		query := `INSERT INTO ` + JournalTable + ` (table_name, schema_name, operation, sql,
+		down_sql, checksum, duration_ms, outcome, error)
+		VALUES (?, COALESCE(NULLIF(?, ''), current_schema()), ?, ?, ?, ?, ?, ?, ?);`
+		if s.run.journalCreated == false {
+			if err = createJournalTable(conn); err == nil {
+				s.run.journalCreated = true
+			}
+		}
+		for _, log := range s.run.journalLog {
+			if err != nil {
+				break
+			}
+			if committed == false && log.Outcome == successOutcome {
+				log.Outcome = rolledBackOutcome
+			}
+			if _, err = conn.Exec(query, log.TableName, log.Schema, log.Operation, log.SQL,
+				log.DownSQL, log.Checksum, log.Duration, log.Outcome, log.Error); err != nil {
+				err = getWrapError(JournalTable, "journal", query, err)
+			}
+		}
+		s.run.journalLog = nil
+	}
+	return
+}
+
+//createJournalTable will create journal table if not exists
+func createJournalTable(conn Executor) (err error) {
+	sql := `CREATE TABLE IF NOT EXISTS ` + JournalTable + ` (
+		id BIGSERIAL PRIMARY KEY,
+		table_name TEXT NOT NULL,
+		schema_name TEXT,
+		operation TEXT NOT NULL,
+		sql TEXT NOT NULL,
+		down_sql TEXT,
+		checksum TEXT,
+		duration_ms BIGINT,
+		db_user TEXT NOT NULL DEFAULT current_user,
+		outcome TEXT NOT NULL,
+		error TEXT,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	ALTER TABLE ` + JournalTable + ` ADD COLUMN IF NOT EXISTS down_sql TEXT;
+	ALTER TABLE ` + JournalTable + ` ADD COLUMN IF NOT EXISTS schema_name TEXT;`
+	if _, err = conn.Exec(sql); err != nil {
+		err = getWrapError(JournalTable, "create journal table", sql, err)
+	}
+	return
+}
+
+//getSchemaChecksum will return checksum of table struct schema
+func (s *Shifter) getSchemaChecksum(tableName string) (checksum string) {
+	if _, exists := s.table[tableName]; exists {
+		sSchema := s.getStructSchema(tableName)
+		cols := make([]string, 0, len(sSchema))
+		for col := range sSchema {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+		h := sha256.New()
+		for _, col := range cols {
+			fmt.Fprintf(h, "%+v\n", sSchema[col])
+		}
+		checksum = hex.EncodeToString(h.Sum(nil))
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package shifter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errJournalDenied = errors.New("permission denied for table shifter_migrations")
+
+//journalFailExecutor is the recorder which fails to execute sql outside transaction
+type journalFailExecutor struct {
+	*Recorder
+}
+
+//Exec will return permission denied error
+func (journalFailExecutor) Exec(query string, params ...interface{}) (Result, error) {
+	return nil, errJournalDenied
+}
+
+func TestJournalError(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter().Journal(true)
+	c := newChange(AddColumnChange, "test_user", "email", "ALTER TABLE test_user ADD email text;\n")
+	err := s.runTx(journalFailExecutor{NewRecorder(nil)}, func(tx Tx) error {
+		return s.exec(tx, c)
+	})
+	assert.True(errors.Is(err, errJournalDenied))
+	assert.Contains(err.Error(), JournalTable)
+	//change is committed even if journal is not written
+	assert.Len(s.AppliedChanges(), 1)
+	assert.Empty(s.run.journalLog)
+
+	//journal error doesn't hide the transaction error
+	execErr := errors.New("column already exists")
+	err = s.runTx(journalFailExecutor{NewRecorder(nil)}, func(tx Tx) error {
+		s.addJournalLog(c, 0, execErr)
+		return execErr
+	})
+	assert.Equal(execErr, err)
+}
@@ -0,0 +1,244 @@
+package shifter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-pg/pg"
+)
+
+//MigrationFormat is the layout of migration files
+type MigrationFormat string
+
+//migration file formats
+const (
+	//MigrateFormat writes NNNN_name.up.sql and NNNN_name.down.sql used by golang-migrate
+	MigrateFormat MigrationFormat = "migrate"
+	//GooseFormat writes NNNN_name.sql having -- +goose Up and -- +goose Down sections
+	GooseFormat MigrationFormat = "goose"
+)
+
+//migrationRegex matches version of migration file name i.e. 0001_add_user.up.sql
+var migrationRegex = regexp.MustCompile(`^(\d+)_.*\.sql$`)
+
+//nameRegex matches characters which are not allowed in migration name
+var nameRegex = regexp.MustCompile(`[^a-z0-9_]+`)
+
+//SetMigrationFormat will set the layout of files written by WriteMigration
+//default is MigrateFormat
+func (s *Shifter) SetMigrationFormat(format MigrationFormat) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.migration = format
+	return s
+}
+
+// WriteMigration will write the changes planned for the given tables
+// as versioned up and down sql migration files in dir. Nothing is executed in database.
+// Version is the next number after the migration files already in dir.
+//
+// Parameters
+//  conn: postgresql connection
+//  dir: directory of migration files
+//  name: name of the migration i.e. add_user_email
+//  models: struct models or table names, if not passed then all tables set in shifter
+// Statements which can't run in a transaction (create index concurrently, backfill)
+// are written in their own version so that migration tool doesn't run them in a transaction
+// with other statements. Changes not having reverse sql are commented in down migration.
+// If there is no change then no file is written.
+func (s *Shifter) WriteMigration(conn *pg.DB, dir, name string, models ...interface{}) (
+	files []string, err error) {
+
+	var (
+		changes []Change
+		version int
+	)
+	if changes, err = s.Plan(conn, models...); err == nil && len(changes) > 0 {
+		if version, err = getNextVersion(dir); err == nil {
+			name = getMigrationName(name)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for i, group := range getMigrationGroup(changes) {
+				var curFiles []string
+				prefix := fmt.Sprintf("%04d_%v", version+i, name)
+				if curFiles, err = s.writeMigrationFile(dir, prefix, group); err != nil {
+					break
+				}
+				files = append(files, curFiles...)
+			}
+		}
+	}
+	return
+}
+
+//writeMigrationFile will write the changes in migration file of shifter format
+func (s *Shifter) writeMigrationFile(dir, prefix string, changes []Change) (
+	files []string, err error) {
+
+	up, down := s.getMigrationSQL(changes)
+	if s.migration == GooseFormat {
+		header := "-- +goose Up\n"
+		if hasNoTx(changes) {
+			header = "-- +goose NO TRANSACTION\n" + header
+		}
+		files = []string{filepath.Join(dir, prefix+".sql")}
+		err = ioutil.WriteFile(files[0], []byte(header+up+"\n-- +goose Down\n"+down), 0644)
+	} else {
+		files = []string{filepath.Join(dir, prefix+".up.sql"), filepath.Join(dir, prefix+".down.sql")}
+		if err = ioutil.WriteFile(files[0], []byte(up), 0644); err == nil {
+			err = ioutil.WriteFile(files[1], []byte(down), 0644)
+		}
+	}
+	return
+}
+
+//getMigrationGroup will group the changes written in a migration file.
+//Change which can't run in a transaction and backfill are in their own group
+//as golang-migrate executes all statements of a file in an implicit transaction
+func getMigrationGroup(changes []Change) (group [][]Change) {
+	var cur []Change
+	for _, c := range changes {
+		if isOwnMigration(c) {
+			if len(cur) > 0 {
+				group = append(group, cur)
+				cur = nil
+			}
+			group = append(group, []Change{c})
+		} else {
+			cur = append(cur, c)
+		}
+	}
+	if len(cur) > 0 {
+		group = append(group, cur)
+	}
+	return
+}
+
+//isOwnMigration will check change is written in its own migration file
+//validate constraint is in its own migration so that it is executed
+//after the constraint added as not valid is committed. Added enum value
+//is in its own migration as it can't run in a transaction before postgresql 12
+//and can't be used in the same transaction after that
+func isOwnMigration(c Change) bool {
+	return c.NoTx || c.Kind == BackfillChange || c.Kind == ValidateChange ||
+		c.Kind == AddEnumValueChange
+}
+
+//getMigrationSQL will return up and down sql of the changes
+//down sql is in reverse order of the changes.
+//Search path is not set in migration of change which can't run in a transaction
+//as it is a single statement and sql executed outside transaction is schema qualified
+func (s *Shifter) getMigrationSQL(changes []Change) (up, down string) {
+	var upSQL, downSQL []string
+	//change which can't run in a transaction is always in its own migration
+	if s.schema != "" && hasNoTx(changes) == false {
+		sql := getSearchPathSQL(s.schema) + ";\n"
+		upSQL, downSQL = []string{sql}, []string{sql}
+	}
+	for _, c := range changes {
+		sql := c.SQL
+		if c.Kind == BackfillChange {
+			sql = getBackfillLoopSQL(sql, s.schema)
+		}
+		upSQL = append(upSQL, getChangeComment(c)+getNoTxComment(c)+s.getGooseStatement(sql))
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if c.DownSQL == "" {
+			if c.Kind != BackfillChange && c.Kind != ValidateChange {
+				downSQL = append(downSQL, getChangeComment(c)+"-- irreversible: no reverse sql\n")
+			}
+		} else {
+			downSQL = append(downSQL, getChangeComment(c)+getNoTxComment(c)+s.getGooseStatement(c.DownSQL))
+		}
+	}
+	up, down = strings.Join(upSQL, "\n"), strings.Join(downSQL, "\n")
+	return
+}
+
+//getGooseStatement will wrap sql having dollar quoted body i.e. DO block or plpgsql function
+//in goose statement annotations so that goose doesn't split it by semicolon
+func (s *Shifter) getGooseStatement(sql string) string {
+	sql = strings.TrimSpace(sql) + "\n"
+	if s.migration == GooseFormat && strings.Contains(sql, "$$") {
+		sql = "-- +goose StatementBegin\n" + sql + "-- +goose StatementEnd\n"
+	}
+	return sql
+}
+
+//getChangeComment will return comment having kind, table and safety of the change
+func getChangeComment(c Change) string {
+	name := c.Table
+	if c.Column != "" {
+		name += "." + c.Column
+	}
+	return fmt.Sprintf("-- %v %v (%v)\n", c.Kind, name, c.Safety)
+}
+
+//getNoTxComment will return annotation of the change which can't run in a transaction
+func getNoTxComment(c Change) (comment string) {
+	if c.NoTx {
+		comment = "-- no transaction: can't run inside a transaction block\n"
+	} else if c.Kind == BackfillChange {
+		comment = "-- no transaction: each batch is committed, needs postgresql 11 or later\n"
+	} else if c.Kind == AddEnumValueChange {
+		comment = "-- no transaction: before postgresql 12 can't run inside a transaction block\n"
+	}
+	return
+}
+
+//hasNoTx will check any of the changes can't run in a transaction
+func hasNoTx(changes []Change) bool {
+	for _, c := range changes {
+		if getNoTxComment(c) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+//getBackfillLoopSQL will return sql which executes the backfill batch till no row is updated
+//as migration file is executed once. Each batch is committed so that rows are not locked
+//till whole table is backfilled. Search path and trigger skip are set in every batch
+//as SET LOCAL is reset on commit
+func getBackfillLoopSQL(sql, schema string) string {
+	setSQL := "\t\t" + getSkipTriggerSQL()
+	if schema != "" {
+		setSQL = "\t\t" + getSearchPathSQL(schema) + ";\n" + setSQL
+	}
+	return fmt.Sprintf("DO $$\nBEGIN\n\tLOOP\n%v\t\t%v\n\t\tEXIT WHEN NOT FOUND;\n\t\tCOMMIT;\n\tEND LOOP;\nEND $$;",
+		setSQL, strings.TrimSpace(sql))
+}
+
+//getNextVersion will return the version after the migration files in dir
+//dir is created if not exists
+func getNextVersion(dir string) (version int, err error) {
+	var files []os.FileInfo
+	if err = os.MkdirAll(dir, 0755); err == nil {
+		if files, err = ioutil.ReadDir(dir); err == nil {
+			for _, file := range files {
+				if match := migrationRegex.FindStringSubmatch(file.Name()); match != nil {
+					if curVersion, _ := strconv.Atoi(match[1]); curVersion > version {
+						version = curVersion
+					}
+				}
+			}
+			version++
+		}
+	}
+	return
+}
+
+//getMigrationName will return name of migration having lower case letters, digits and underscore
+func getMigrationName(name string) string {
+	name = nameRegex.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "_")
+	if name = strings.Trim(name, "_"); name == "" {
+		name = "migration"
+	}
+	return name
+}
@@ -0,0 +1,132 @@
+package shifter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNextVersion(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "migration")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	version, err := getNextVersion(filepath.Join(dir, "migrations"))
+	assert.NoError(err)
+	assert.Equal(1, version)
+
+	for _, file := range []string{"0001_init.up.sql", "0001_init.down.sql", "0012_add_user.sql", "README.md"} {
+		assert.NoError(ioutil.WriteFile(filepath.Join(dir, file), nil, 0644))
+	}
+	version, err = getNextVersion(dir)
+	assert.NoError(err)
+	assert.Equal(13, version)
+}
+
+func TestGetMigrationName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("add_user_email", getMigrationName(" Add User-Email "))
+	assert.Equal("migration", getMigrationName("--"))
+}
+
+func TestGetMigrationSQL(t *testing.T) {
+	assert := assert.New(t)
+	addCol := newChange(AddColumnChange, "test_user", "email", "ALTER TABLE test_user ADD COLUMN email text;\n")
+	addCol.DownSQL = "ALTER TABLE test_user DROP COLUMN email;\n"
+	idx := newChange(CreateIndexChange, "test_user", "", "CREATE INDEX CONCURRENTLY idx_email ON test_user (email);\n")
+	idx.NoTx = true
+	enumVal := newChange(AddEnumValueChange, "status_type", "", "ALTER type status_type ADD VALUE IF NOT EXISTS 'hold';\n")
+	backfill := newChange(BackfillChange, "test_user", "email", getBackfillSQL("test_user", "email", "''", 1000))
+	changes := []Change{addCol, backfill, idx, enumVal}
+	assert.True(hasNoTx(changes))
+	assert.False(hasNoTx(changes[:1]))
+
+	//backfill and statement which can't run in transaction are in their own migration
+	group := getMigrationGroup(changes)
+	assert.Equal([][]Change{{addCol}, {backfill}, {idx}, {enumVal}}, group)
+	assert.Len(getMigrationGroup([]Change{addCol, enumVal, idx, addCol}), 4)
+
+	up, down := NewShifter().getMigrationSQL(changes)
+	assert.Equal("-- add column test_user.email (safe)\n"+
+		"ALTER TABLE test_user ADD COLUMN email text;\n\n"+
+		"-- backfill column test_user.email (safe)\n"+
+		"-- no transaction: each batch is committed, needs postgresql 11 or later\n"+
+		"DO $$\nBEGIN\n\tLOOP\n\t\tSET LOCAL pg_shifter.skip_trigger = on;\n"+
+		"\t\tUPDATE test_user SET email = '' WHERE ctid IN "+
+		"(SELECT ctid FROM test_user WHERE email IS NULL LIMIT 1000);\n"+
+		"\t\tEXIT WHEN NOT FOUND;\n\t\tCOMMIT;\n\tEND LOOP;\nEND $$;\n\n"+
+		"-- create index test_user (safe)\n"+
+		"-- no transaction: can't run inside a transaction block\n"+
+		"CREATE INDEX CONCURRENTLY idx_email ON test_user (email);\n\n"+
+		"-- add enum value status_type (safe)\n"+
+		"-- no transaction: before postgresql 12 can't run inside a transaction block\n"+
+		"ALTER type status_type ADD VALUE IF NOT EXISTS 'hold';\n", up)
+	assert.Equal("-- add enum value status_type (safe)\n-- irreversible: no reverse sql\n\n"+
+		"-- create index test_user (safe)\n-- irreversible: no reverse sql\n\n"+
+		"-- add column test_user.email (safe)\n"+
+		"ALTER TABLE test_user DROP COLUMN email;\n", down)
+
+	s := NewShifter().Schema("tenant_1")
+	up, _ = s.getMigrationSQL(changes[:1])
+	assert.Equal(`SET LOCAL search_path TO "tenant_1", public;`+"\n\n"+
+		"-- add column test_user.email (safe)\n"+
+		"ALTER TABLE test_user ADD COLUMN email text;\n", up)
+	//single statement outside transaction doesn't set search path
+	up, _ = s.getMigrationSQL([]Change{idx})
+	assert.NotContains(up, "search_path")
+	up, _ = s.getMigrationSQL([]Change{backfill})
+	assert.Contains(up, "\t\t"+`SET LOCAL search_path TO "tenant_1", public;`+"\n")
+
+	//validate is executed after not valid check is committed
+	addCheck := newChange(AddCheckChange, "test_user", "", getAddCheckSQL("test_user", "test_user_age_check", "age > 0"))
+	validate := newChange(ValidateChange, "test_user", "", "ALTER TABLE test_user VALIDATE CONSTRAINT test_user_age_check;\n")
+	assert.Equal([][]Change{{addCol, addCheck}, {validate}}, getMigrationGroup([]Change{addCol, addCheck, validate}))
+	up, _ = s.getMigrationSQL([]Change{validate})
+	assert.Contains(up, `SET LOCAL search_path TO "tenant_1", public;`)
+
+	//added enum value is in its own migration with schema qualified enum
+	assert.Equal([][]Change{{addCol}, {enumVal}, {addCol}}, getMigrationGroup([]Change{addCol, enumVal, addCol}))
+	s.run.dryRun = true
+	_, err := s.addEnumVal(nil, "test_user", "status_type", "hold")
+	assert.NoError(err)
+	if assert.Len(s.run.changes, 1) {
+		up, _ = s.getMigrationSQL(s.run.changes)
+		assert.NotContains(up, "search_path")
+		assert.Contains(up, "ALTER type tenant_1.status_type ADD VALUE IF NOT EXISTS 'hold';")
+	}
+
+	//goose doesn't split dollar quoted body
+	up, _ = NewShifter().SetMigrationFormat(GooseFormat).getMigrationSQL([]Change{backfill})
+	assert.Contains(up, "-- +goose StatementBegin\nDO $$\n")
+	assert.Contains(up, "END $$;\n-- +goose StatementEnd\n")
+}
+
+func TestWriteMigrationFile(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "migration")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	idx := newChange(CreateIndexChange, "test_user", "", "CREATE INDEX CONCURRENTLY idx_email ON test_user (email);\n")
+	idx.NoTx = true
+	files, err := NewShifter().writeMigrationFile(dir, "0002_add_index", []Change{idx})
+	assert.NoError(err)
+	assert.Equal([]string{filepath.Join(dir, "0002_add_index.up.sql"),
+		filepath.Join(dir, "0002_add_index.down.sql")}, files)
+
+	trigger := newChange(CreateTriggerChange, "test_user", "",
+		"CREATE OR REPLACE FUNCTION test_user_fn() RETURNS trigger AS $$ BEGIN RETURN NEW; END; $$ LANGUAGE 'plpgsql';")
+	files, err = NewShifter().SetMigrationFormat(GooseFormat).writeMigrationFile(dir, "0003_trigger",
+		[]Change{trigger, idx})
+	assert.NoError(err)
+	if assert.Len(files, 1) {
+		data, err := ioutil.ReadFile(files[0])
+		assert.NoError(err)
+		assert.Contains(string(data), "-- +goose NO TRANSACTION\n-- +goose Up\n")
+		assert.Contains(string(data), "-- +goose StatementBegin\nCREATE OR REPLACE FUNCTION test_user_fn()")
+	}
+}
@@ -1,40 +1,90 @@
 package model
 
+import "time"
+
 //ColSchema : Table Column Schema Model
 type ColSchema struct {
-	TableName         string `sql:"-"`
-	StructColumnName  string `sql:"-"`
-	ColumnName        string `sql:"column_name"`
-	ColumnDefault     string `sql:"column_default"`
-	DataType          string `sql:"data_type"`
-	UdtName           string `sql:"udt_name"`
-	IsNullable        string `sql:"is_nullable"`
-	CharMaxLen        string `sql:"character_maximum_length"`
-	ConstraintType    string `sql:"constraint_type"`
-	ConstraintName    string `sql:"constraint_name"`
-	IsDeferrable      string `sql:"is_deferrable"`
-	InitiallyDeferred string `sql:"initially_deferred"`
-	ForeignTableName  string `sql:"foreign_table_name"`
-	ForeignColumnName string `sql:"foreign_column_name"`
-	UpdateType        string `sql:"confupdtype"`
-	DeleteType        string `sql:"confdeltype"`
-	SeqName           string `sql:"seq_name"`
-	SeqDataType       string `sql:"seq_data_type"`
-	Position          int    `sql:"position"`
-	IsFkUnique        bool   `sql:"-"`
-	FkUniqueName      string `sql:"-"`
-	DefaultExists     bool   `sql:"-"`
+	TableName         string `sql:"-" json:"-" yaml:"-"`
+	StructColumnName  string `sql:"-" json:"-" yaml:"-"`
+	ColumnName        string `sql:"column_name" json:"column_name,omitempty" yaml:"column_name,omitempty"`
+	ColumnDefault     string `sql:"column_default" json:"column_default,omitempty" yaml:"column_default,omitempty"`
+	DataType          string `sql:"data_type" json:"data_type,omitempty" yaml:"data_type,omitempty"`
+	UdtName           string `sql:"udt_name" json:"udt_name,omitempty" yaml:"udt_name,omitempty"`
+	IsNullable        string `sql:"is_nullable" json:"is_nullable,omitempty" yaml:"is_nullable,omitempty"`
+	CharMaxLen        string `sql:"character_maximum_length" json:"character_maximum_length,omitempty" yaml:"character_maximum_length,omitempty"`
+	ConstraintType    string `sql:"constraint_type" json:"constraint_type,omitempty" yaml:"constraint_type,omitempty"`
+	ConstraintName    string `sql:"constraint_name" json:"constraint_name,omitempty" yaml:"constraint_name,omitempty"`
+	IsDeferrable      string `sql:"is_deferrable" json:"is_deferrable,omitempty" yaml:"is_deferrable,omitempty"`
+	InitiallyDeferred string `sql:"initially_deferred" json:"initially_deferred,omitempty" yaml:"initially_deferred,omitempty"`
+	ForeignTableName  string `sql:"foreign_table_name" json:"foreign_table_name,omitempty" yaml:"foreign_table_name,omitempty"`
+	ForeignColumnName string `sql:"foreign_column_name" json:"foreign_column_name,omitempty" yaml:"foreign_column_name,omitempty"`
+	UpdateType        string `sql:"confupdtype" json:"confupdtype,omitempty" yaml:"confupdtype,omitempty"`
+	DeleteType        string `sql:"confdeltype" json:"confdeltype,omitempty" yaml:"confdeltype,omitempty"`
+	SeqName           string `sql:"seq_name" json:"seq_name,omitempty" yaml:"seq_name,omitempty"`
+	SeqDataType       string `sql:"seq_data_type" json:"seq_data_type,omitempty" yaml:"seq_data_type,omitempty"`
+	Position          int    `sql:"position" json:"position,omitempty" yaml:"position,omitempty"`
+	IsFkUnique        bool   `sql:"-" json:"-" yaml:"-"`
+	FkUniqueName      string `sql:"-" json:"-" yaml:"-"`
+	DefaultExists     bool   `sql:"-" json:"-" yaml:"-"`
 }
 
 //UKSchema : Unique Schema Model
 type UKSchema struct {
-	ConstraintName string `sql:"conname"`
-	Columns        string `sql:"col"`
+	ConstraintName string `sql:"conname" json:"conname,omitempty" yaml:"conname,omitempty"`
+	Columns        string `sql:"col" json:"col,omitempty" yaml:"col,omitempty"`
+}
+
+//KeySchema : Composite Primary/Foreign Key Schema Model
+type KeySchema struct {
+	TableName         string `sql:"-" json:"-" yaml:"-"`
+	ConstraintName    string `sql:"conname" json:"conname,omitempty" yaml:"conname,omitempty"`
+	ConstraintType    string `sql:"constraint_type" json:"constraint_type,omitempty" yaml:"constraint_type,omitempty"`
+	Columns           string `sql:"col" json:"col,omitempty" yaml:"col,omitempty"`
+	ForeignTableName  string `sql:"foreign_table_name" json:"foreign_table_name,omitempty" yaml:"foreign_table_name,omitempty"`
+	ForeignColumns    string `sql:"foreign_col" json:"foreign_col,omitempty" yaml:"foreign_col,omitempty"`
+	UpdateType        string `sql:"confupdtype" json:"confupdtype,omitempty" yaml:"confupdtype,omitempty"`
+	DeleteType        string `sql:"confdeltype" json:"confdeltype,omitempty" yaml:"confdeltype,omitempty"`
+	IsDeferrable      string `sql:"is_deferrable" json:"is_deferrable,omitempty" yaml:"is_deferrable,omitempty"`
+	InitiallyDeferred string `sql:"initially_deferred" json:"initially_deferred,omitempty" yaml:"initially_deferred,omitempty"`
+}
+
+//CheckSchema : Check Constraint Schema Model
+type CheckSchema struct {
+	ConstraintName string `sql:"conname" json:"conname,omitempty" yaml:"conname,omitempty"`
+	Definition     string `sql:"def" json:"def,omitempty" yaml:"def,omitempty"`
+}
+
+//ForeignKey : multi column foreign key returned by struct ForeignKeys() method
+//OnDelete and OnUpdate can be restrict, cascade, set null, set default or no action
+type ForeignKey struct {
+	Columns           []string
+	RefTable          string
+	RefColumns        []string
+	OnDelete          string
+	OnUpdate          string
+	Deferrable        bool
+	InitiallyDeferred bool
 }
 
 //Index model
 type Index struct {
-	IdxName string `sql:"index_name"`
-	IType   string `sql:"itype"`
-	Columns string `sql:"col"`
+	IdxName string `sql:"index_name" json:"index_name,omitempty" yaml:"index_name,omitempty"`
+	IType   string `sql:"itype" json:"itype,omitempty" yaml:"itype,omitempty"`
+	Columns string `sql:"col" json:"col,omitempty" yaml:"col,omitempty"`
+}
+
+//MigrationLog : shifter migration journal model
+type MigrationLog struct {
+	ID        int       `sql:"id"`
+	TableName string    `sql:"table_name"`
+	Schema    string    `sql:"schema_name"`
+	Operation string    `sql:"operation"`
+	SQL       string    `sql:"sql"`
+	DownSQL   string    `sql:"down_sql"`
+	Checksum  string    `sql:"checksum"`
+	Duration  int64     `sql:"duration_ms"`
+	DBUser    string    `sql:"db_user"`
+	Outcome   string    `sql:"outcome"`
+	Error     string    `sql:"error"`
+	AppliedAt time.Time `sql:"applied_at"`
 }
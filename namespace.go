@@ -0,0 +1,95 @@
+package shifter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//Schema will set the postgresql schema used for tables which are not
+//schema qualified in struct sql tag i.e. sql:"billing.invoice".
+//Every transaction of shifter sets the search path to this schema
+//so tables, enums, indexes, triggers and history tables are created in it.
+//By default the database search path is used.
+func (s *Shifter) Schema(schema string) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schema = schema
+	return s
+}
+
+//begin will begin the transaction and set the search path to shifter schema
+//and lock and statement timeout of the transaction
+//executor is kept in run to execute the steps after commit
+func (s *Shifter) begin(conn Executor) (tx Tx, err error) {
+	s.run.conn = conn
+	sql := s.getTimeoutSQL()
+	if s.schema != "" {
+		sql = getSearchPathSQL(s.schema) + ";\n" + sql
+	}
+	if tx, err = conn.Begin(); err == nil && sql != "" {
+		if _, err = tx.Exec(sql); err != nil {
+			tx.Rollback()
+		}
+	}
+	return
+}
+
+//getSearchPathSQL will return set search path sql of the transaction
+//public is kept in search path for extension types
+func getSearchPathSQL(schema string) (sql string) {
+	sql = fmt.Sprintf("SET LOCAL search_path TO %v, public", quoteIdent(schema))
+	return
+}
+
+//getSessionSearchPathSQL will return set search path sql of the session
+//used in sql files which may not be executed in a transaction
+func getSessionSearchPathSQL(schema string) string {
+	return strings.Replace(getSearchPathSQL(schema), "SET LOCAL", "SET", 1)
+}
+
+//quoteIdent will quote the postgresql identifier
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+//getTableSchemaName will return schema of the table
+//either from schema qualified table name or shifter schema
+func (s *Shifter) getTableSchemaName(tableName string) (schema string) {
+	if schema, _ = util.SplitTableName(tableName); schema == "" {
+		schema = s.schema
+	}
+	return
+}
+
+//getQualifiedTableName will return table name qualified by shifter schema
+//used for sql executed outside transaction as search path is not set there
+func (s *Shifter) getQualifiedTableName(tableName string) string {
+	if schema, _ := util.SplitTableName(tableName); schema == "" && s.schema != "" {
+		tableName = s.schema + "." + tableName
+	}
+	return tableName
+}
+
+//createSchema will create schema of the table if not exists
+func (s *Shifter) createSchema(tx Tx, tableName string) (err error) {
+	if schema := s.getTableSchemaName(tableName); schema != "" {
+		if _, created := s.run.schemas[schema]; created == false {
+			s.run.schemas[schema] = struct{}{}
+			sql := fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %v;\n", quoteIdent(schema))
+			err = s.exec(tx, newChange(CreateSchemaChange, schema, "", sql))
+		}
+	}
+	return
+}
+
+//getRefTableName will return referenced table name as read from database
+//referenced table in the same schema as table is not schema qualified
+func getRefTableName(tableName, refTable string) string {
+	schema, _ := util.SplitTableName(tableName)
+	if refSchema, table := util.SplitTableName(refTable); refSchema == schema {
+		refTable = table
+	}
+	return refTable
+}
@@ -0,0 +1,102 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/util"
+	"github.com/stretchr/testify/assert"
+)
+
+type localInvoice2 struct {
+	tableName  struct{} `sql:"billing.invoice"`
+	InvoiceID  int      `sql:"invoice_id,type:serial PRIMARY KEY"`
+	CustomerID int      `sql:"customer_id,type:int NOT NULL REFERENCES billing.customer(customer_id)"`
+	OrderID    int      `sql:"order_id,type:int NULL REFERENCES sales.orders(order_id)"`
+}
+
+func TestSplitTableName(t *testing.T) {
+	assert := assert.New(t)
+	schema, table := util.SplitTableName("billing.invoice")
+	assert.Equal("billing", schema)
+	assert.Equal("invoice", table)
+	schema, table = util.SplitTableName("invoice")
+	assert.Equal("", schema)
+	assert.Equal("invoice", table)
+	assert.Equal("invoice_history", util.GetBareName(util.GetHistoryTableName("billing.invoice")))
+}
+
+func TestSchema(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&localInvoice2{})
+	assert.Equal([]string{"billing.invoice"}, s.TableNames())
+	assert.Equal("billing", s.getTableSchemaName("billing.invoice"))
+	assert.Equal("", s.getTableSchemaName("invoice"))
+	s.Schema("tenant_1")
+	assert.Equal("tenant_1", s.getTableSchemaName("invoice"))
+	assert.Equal(`SET LOCAL search_path TO "tenant_1", public`, getSearchPathSQL("tenant_1"))
+
+	sSchema := s.GetStructSchema("billing.invoice")
+	assert.Equal("invoice", sSchema["invoice_id"].ForeignTableName)
+	assert.Equal("customer", sSchema["customer_id"].ForeignTableName)
+	assert.Equal("sales.orders", sSchema["order_id"].ForeignTableName)
+	assert.Equal("invoice_customer_id_fkey", getConstraintName(sSchema["customer_id"]))
+
+	assert.Equal("idx_invoice_customer_id", getIndexName("billing.invoice", "customer_id"))
+	assert.Equal("DROP INDEX IF EXISTS billing.idx_invoice_customer_id;\n",
+		getDropIndexSQL("billing.invoice", "idx_invoice_customer_id"))
+	assert.Equal("DROP INDEX IF EXISTS idx_invoice_customer_id;\n",
+		getDropIndexSQL("invoice", "idx_invoice_customer_id"))
+
+	s.run.dryRun = true
+	assert.NoError(s.createSchema(nil, "billing.invoice"))
+	assert.NoError(s.createSchema(nil, "billing.customer"))
+	if assert.Len(s.run.changes, 1) {
+		assert.Equal(CreateSchemaChange, s.run.changes[0].Kind)
+		assert.Equal("CREATE SCHEMA IF NOT EXISTS \"billing\";\n", s.run.changes[0].SQL)
+	}
+}
+
+type localInvoice3 struct {
+	tableName struct{} `sql:"billing.invoice"`
+	InvoiceID int      `sql:"invoice_id,type:serial PRIMARY KEY"`
+	Status    string   `sql:"status,type:invoice_status NOT NULL"`
+}
+
+func (localInvoice3) Enum() map[string][]string {
+	return map[string][]string{"invoice_status": {"open", "paid"}}
+}
+
+func TestSchemaEnum(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&localInvoice3{})
+	assert.Equal("billing.invoice_status", s.getEnumTypeName("billing.invoice", "invoice_status"))
+	assert.Equal("billing.invoice_status", s.getEnumTypeName("billing.invoice", "billing.invoice_status"))
+	assert.Equal("int", s.getEnumTypeName("billing.invoice", "int"))
+
+	changes, err := s.PlanOffline(Snapshot{}, &localInvoice3{})
+	assert.NoError(err)
+	var enumSQL, tableSQL string
+	for _, c := range changes {
+		switch c.Kind {
+		case CreateEnumChange:
+			enumSQL = c.SQL
+		case CreateTableChange:
+			tableSQL = c.SQL
+		}
+	}
+	assert.Contains(enumSQL, "CREATE type billing.invoice_status AS ENUM('open','paid');")
+	assert.Contains(tableSQL, `"status" billing.invoice_status NOT NULL`)
+
+	//enum existing in table schema is looked up by qualified name
+	s = NewShifter(&localInvoice3{})
+	changes, err = s.PlanOffline(Snapshot{Enums: map[string][]string{
+		"billing.invoice_status": {"open"}}}, &localInvoice3{})
+	assert.NoError(err)
+	enumSQL = ""
+	for _, c := range changes {
+		if c.Kind == CreateEnumChange || c.Kind == AddEnumValueChange {
+			enumSQL += c.SQL
+		}
+	}
+	assert.Equal("ALTER type billing.invoice_status ADD VALUE IF NOT EXISTS 'paid';", enumSQL)
+}
@@ -0,0 +1,273 @@
+package shifter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//errNullBackfill is returned if column is backfilled with null default
+//as null rows are never filled and backfill doesn't end
+var errNullBackfill = errors.New("column can't be backfilled with null default")
+
+//funcRegex matches function calls in column default i.e. gen_random_uuid()
+var funcRegex = regexp.MustCompile(`([a-z_][a-z0-9_]*)\s*\(`)
+
+//Online will enable online alter for large tables so that table is not
+//locked while existing rows are scanned or rewritten.
+//Not null is set by validating a not valid check constraint and
+//new column with volatile default is added without default value, backfilled
+//in batches and then constrained. Each step after the alter is executed
+//in its own transaction once the alter is committed
+func (s *Shifter) Online(enable bool) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.online = enable
+	return s
+}
+
+//SetBatchSize will set number of rows updated in a transaction
+//while backfilling new column in online alter. Default is 1000
+func (s *Shifter) SetBatchSize(size int) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchSize = size
+	return s
+}
+
+//getBatchSize will return backfill batch size
+func (s *Shifter) getBatchSize() (size int) {
+	if size = s.batchSize; size < 1 {
+		size = defaultBatchSize
+	}
+	return
+}
+
+//setNotNullOnline will add not null check constraint as not valid
+//validation of check and set not null are queued as online steps.
+//If not valid check is left by previous failed run then it is validated
+func (s *Shifter) setNotNullOnline(tx Tx, schema model.ColSchema,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	var exists bool
+	name := getNotNullCheckName(schema.TableName, schema.ColumnName)
+	if exists, err = s.checkExists(tx, schema.TableName, name); err == nil {
+		if exists {
+			isAlter = true
+			s.queueNotNull(schema.TableName, schema.ColumnName)
+		} else {
+			sql := fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v CHECK (%v IS NOT NULL) NOT VALID;\n",
+				schema.TableName, name, schema.ColumnName)
+			c := newChange(ModifyNotNullChange, schema.TableName, schema.ColumnName, sql)
+			c.DownSQL = getDropConstraintSQL(schema.TableName, name)
+			if isAlter, err = s.execByChoice(tx, c, skipPrompt); err == nil && isAlter {
+				s.queueNotNull(schema.TableName, schema.ColumnName)
+			}
+		}
+	}
+	return
+}
+
+//checkExists will check check constraint exists in table
+func (s *Shifter) checkExists(tx Tx, tName, name string) (exists bool, err error) {
+	var tCheck []model.CheckSchema
+	if tCheck, err = s.catalog(tx).Check(tName); err == nil {
+		for _, curCheck := range tCheck {
+			if curCheck.ConstraintName == name {
+				exists = true
+				break
+			}
+		}
+	}
+	return
+}
+
+//queueNotNull will queue the online steps to validate not null check constraint
+//and then set not null. postgresql uses the valid check to skip table scan
+//while setting not null so check is dropped after that
+func (s *Shifter) queueNotNull(tName, cName string) {
+	name := getNotNullCheckName(tName, cName)
+	s.queueValidate(tName, cName, name)
+
+	sql := getNotNullColSQL(tName, cName, set) + ";\n" +
+		getDropConstraintSQL(tName, name)
+	notNull := newChange(ModifyNotNullChange, tName, cName, sql)
+	notNull.DownSQL = getNotNullColSQL(tName, cName, drop) + ";\n"
+	s.run.online = append(s.run.online, notNull)
+}
+
+//getNotNullCheckName will return name of not null check constraint of online alter
+func getNotNullCheckName(tName, cName string) string {
+	name := fmt.Sprintf("%v_%v_%v", util.GetBareName(tName), cName, notNullSuffix)
+	return util.GetUniqueStrByLen(name, 64)
+}
+
+//isOnlineAddCol will check column need to be added online
+//column having volatile default is added online as adding it rewrites the table
+func (s *Shifter) isOnlineAddCol(tx Tx, schema model.ColSchema) (
+	online bool, err error) {
+
+	if s.online && schema.ColumnDefault != "" && schema.SeqName == "" {
+		online, err = isVolatileDefault(s.catalog(tx), trimDefaultType(schema))
+	}
+	return
+}
+
+//addColOnline will add column without default value and not null
+//and set the default for new rows. Backfill of existing rows and not null
+//are queued as online steps
+func (s *Shifter) addColOnline(tx Tx, schema model.ColSchema,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	tName, cName := schema.TableName, schema.ColumnName
+	defVal := trimDefaultType(schema)
+	if strings.EqualFold(defVal, null) {
+		err = getWrapError(tName, "backfill", defVal, errNullBackfill)
+		return
+	}
+	dType := getStructDataType(s.getEnumColSchema(schema))
+	sql := getAddColSQL(tName, cName, dType)
+	downSQL := getDropColSQL(tName, cName)
+	if cSQL := getAddConstraintSQL(schema); cSQL != "" {
+		sql += "," + cSQL
+	} else {
+		sql += ";\n"
+	}
+	sql += getSetDefaultSQL(tName, cName, defVal)
+
+	//checking history table exists
+	if s.hisExists && cName != "updated_at" {
+		hName := util.GetHistoryTableName(tName)
+		sql += getAddColSQL(hName, cName, dType) + ";\n"
+		downSQL += getDropColSQL(hName, cName)
+	}
+	//history alter sql end
+
+	c := newChange(AddColumnChange, tName, cName, sql)
+	c.DownSQL = downSQL
+	if isAlter, err = s.execByChoice(tx, c, skipPrompt); err == nil && isAlter {
+		s.run.online = append(s.run.online, newChange(BackfillChange, tName, cName,
+			getBackfillSQL(tName, cName, defVal, s.getBatchSize())))
+		if schema.IsNullable == no {
+			name := getNotNullCheckName(tName, cName)
+			sql = fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v CHECK (%v IS NOT NULL) NOT VALID;\n",
+				tName, name, cName)
+			s.run.online = append(s.run.online, newChange(ModifyNotNullChange, tName, cName, sql))
+			s.queueNotNull(tName, cName)
+		}
+	}
+	return
+}
+
+//getBackfillSQL will return sql which sets default value in a batch of rows
+//shifter update triggers are skipped while backfilling using getSkipTriggerSQL()
+//so that updated_at is not changed and history rows are not added
+func getBackfillSQL(tName, cName, defVal string, batchSize int) (sql string) {
+	sql = fmt.Sprintf("UPDATE %v SET %v = %v WHERE ctid IN "+
+		"(SELECT ctid FROM %v WHERE %v IS NULL LIMIT %v);\n",
+		tName, cName, defVal, tName, cName, batchSize)
+	return
+}
+
+//getSkipTriggerSQL will return sql which skips shifter update triggers in the transaction
+func getSkipTriggerSQL() string {
+	return fmt.Sprintf("SET LOCAL %v = on;\n", skipTriggerSetting)
+}
+
+//isVolatileDefault will check default value calls a volatile function
+//i.e. random() or gen_random_uuid()
+func isVolatileDefault(cat Catalog, defVal string) (volatile bool, err error) {
+	var funcs []string
+	for _, match := range funcRegex.FindAllStringSubmatch(strings.ToLower(defVal), -1) {
+		funcs = append(funcs, match[1])
+	}
+	if len(funcs) > 0 {
+		volatile, err = cat.VolatileFunction(funcs)
+	}
+	return
+}
+
+//getVolatileFunction will check any of the given functions is volatile in database
+func getVolatileFunction(tx Tx, funcs []string) (volatile bool, err error) {
+	var count int
+	query := `SELECT count(*) FROM pg_proc WHERE proname IN (?) AND provolatile = 'v';`
+	if err = tx.Query(&count, query, pg.In(funcs)); err == nil {
+		volatile = count > 0
+	} else {
+		err = getWrapError(strings.Join(funcs, ","), "function volatility", query, err)
+	}
+	return
+}
+
+//getDBVolatileFunction will return names of all volatile functions in database
+func getDBVolatileFunction(tx Tx) (funcs []string, err error) {
+	query := `SELECT DISTINCT proname FROM pg_proc WHERE provolatile = 'v' ORDER BY proname;`
+	if err = tx.Query(&funcs, query); err != nil {
+		err = getWrapError("pg_proc", "function volatility", query, err)
+	}
+	return
+}
+
+//execOnline will execute the queued online steps each in its own transaction
+//backfill is executed in batches till no row is updated
+//and NoTx step is executed without transaction
+//in dry run steps are recorded in plan
+func (s *Shifter) execOnline(conn Executor) (err error) {
+	steps := s.run.online
+	s.run.online = nil
+	for _, c := range steps {
+		if s.run.dryRun {
+			s.recordChange(c)
+		} else if c.Kind == BackfillChange {
+			err = s.backfill(conn, c)
+		} else if c.NoTx {
+			err = s.execNoTx(conn, c)
+		} else {
+			_, err = s.execStep(conn, c)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return
+}
+
+//backfill will execute backfill batch in its own transaction till a batch updates
+//less rows than batch size. backfill is stopped if shifter context is done
+func (s *Shifter) backfill(conn Executor, c Change) (err error) {
+	batchSize := s.getBatchSize()
+	for rows := batchSize; rows >= batchSize && err == nil; {
+		if s.ctx != nil {
+			if err = s.ctx.Err(); err != nil {
+				break
+			}
+		}
+		rows, err = s.execStep(conn, c)
+	}
+	return
+}
+
+//execStep will execute online step in its own transaction
+//shifter update triggers are skipped in backfill transaction
+func (s *Shifter) execStep(conn Executor, c Change) (rows int, err error) {
+	err = s.runTx(conn, func(tx Tx) (err error) {
+		var res Result
+		if c.Kind == BackfillChange {
+			if _, err = tx.Exec(getSkipTriggerSQL()); err != nil {
+				err = getWrapError(c.Table, string(c.Kind), getSkipTriggerSQL(), err)
+			}
+		}
+		if err == nil {
+			if res, err = s.execResult(tx, c); err == nil {
+				rows = res.RowsAffected()
+			}
+		}
+		return
+	})
+	return
+}
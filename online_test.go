@@ -0,0 +1,115 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetNotNullOnline(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter().Online(true)
+	s.run.dryRun = true
+	s.run.catalog = Snapshot{}
+	tSchema := model.ColSchema{TableName: "test_user", ColumnName: "email", IsNullable: yes}
+	sSchema := model.ColSchema{TableName: "test_user", ColumnName: "email", IsNullable: no}
+	isAlter, err := s.modifyNotNullConstraint(nil, tSchema, sSchema, true)
+	assert.NoError(err)
+	assert.True(isAlter)
+	assert.Len(s.run.online, 2)
+	assert.NoError(s.execOnline(nil))
+	assert.Empty(s.run.online)
+	if assert.Len(s.run.changes, 3) {
+		assert.Equal("ALTER TABLE test_user ADD CONSTRAINT test_user_email_not_null "+
+			"CHECK (email IS NOT NULL) NOT VALID;\n", s.run.changes[0].SQL)
+		assert.Equal(ValidateChange, s.run.changes[1].Kind)
+		assert.Equal("ALTER TABLE test_user VALIDATE CONSTRAINT test_user_email_not_null;\n",
+			s.run.changes[1].SQL)
+		assert.Equal("ALTER TABLE test_user ALTER COLUMN email SET NOT NULL;\n"+
+			"ALTER TABLE test_user DROP CONSTRAINT test_user_email_not_null;\n",
+			s.run.changes[2].SQL)
+	}
+
+	//not valid check left by failed run is validated
+	s = NewShifter(&db.TestUser{}).Online(true)
+	s.run.dryRun = true
+	s.run.catalog = Snapshot{Tables: map[string]TableSnapshot{"test_user": {
+		Checks: []model.CheckSchema{{ConstraintName: "test_user_email_not_null",
+			Definition: "CHECK ((email IS NOT NULL)) NOT VALID"}},
+	}}}
+	isAlter, err = s.modifyNotNullConstraint(nil, tSchema, sSchema, true)
+	assert.NoError(err)
+	assert.True(isAlter)
+	assert.Empty(s.run.changes)
+	assert.NoError(s.execOnline(nil))
+	if assert.Len(s.run.changes, 2) {
+		assert.Equal(ValidateChange, s.run.changes[0].Kind)
+	}
+	_, isAlter, err = s.dropCheck(nil, "test_user", true)
+	assert.NoError(err)
+	assert.False(isAlter)
+
+	//dropping not null is not done online
+	s = NewShifter().Online(true)
+	s.run.dryRun = true
+	_, err = s.modifyNotNullConstraint(nil, sSchema, tSchema, true)
+	assert.NoError(err)
+	assert.Empty(s.run.online)
+	assert.Len(s.run.changes, 1)
+}
+
+func TestAddColOnline(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter().Online(true).SetBatchSize(500)
+	s.run.dryRun = true
+	schema := model.ColSchema{TableName: "test_user", ColumnName: "token", DataType: "uuid",
+		ColumnDefault: "gen_random_uuid()", IsNullable: no}
+	isAlter, err := s.addColOnline(nil, schema, true)
+	assert.NoError(err)
+	assert.True(isAlter)
+	assert.NoError(s.execOnline(nil))
+	if assert.Len(s.run.changes, 5) {
+		assert.Equal("ALTER TABLE test_user ADD token uuid;\n"+
+			"ALTER TABLE test_user ALTER COLUMN token SET DEFAULT gen_random_uuid();\n",
+			s.run.changes[0].SQL)
+		assert.Equal(BackfillChange, s.run.changes[1].Kind)
+		assert.Equal("UPDATE test_user SET token = gen_random_uuid() WHERE ctid IN "+
+			"(SELECT ctid FROM test_user WHERE token IS NULL LIMIT 500);\n", s.run.changes[1].SQL)
+		assert.Equal(ModifyNotNullChange, s.run.changes[2].Kind)
+		assert.Equal(ValidateChange, s.run.changes[3].Kind)
+		assert.Equal(ModifyNotNullChange, s.run.changes[4].Kind)
+	}
+
+	//null default is not backfilled
+	schema.ColumnDefault = "NULL"
+	_, err = NewShifter().Online(true).addColOnline(nil, schema, true)
+	assert.Error(err)
+
+	//literal default is added in single step
+	volatile, err := isVolatileDefault(nil, "'active'")
+	assert.NoError(err)
+	assert.False(volatile)
+	schema.ColumnDefault = "gen_random_uuid()"
+	online, err := NewShifter().isOnlineAddCol(nil, schema)
+	assert.NoError(err)
+	assert.False(online)
+	assert.Equal(defaultBatchSize, NewShifter().getBatchSize())
+}
+
+func TestBackfillSkipTrigger(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRecorder(nil)
+	s := NewShifter(&db.TestUser{}).SetExecutor(r)
+	c := newChange(BackfillChange, "test_user", "token",
+		getBackfillSQL("test_user", "token", "gen_random_uuid()", 10))
+	//recorder updates no row so backfill is stopped after first batch
+	assert.NoError(s.backfill(s.getExecutor(nil), c))
+	assert.Equal("BEGIN;\nSET LOCAL pg_shifter.skip_trigger = on;\n"+
+		getStatement(c.SQL)+"COMMIT;\n", r.Script())
+
+	trigger := s.GetTrigger("test_user")
+	assert.Contains(trigger, "IF current_setting('pg_shifter.skip_trigger', true) = 'on'")
+	assert.Contains(trigger, "IF current_setting('pg_shifter.skip_trigger', true) IS DISTINCT FROM 'on'")
+}
@@ -0,0 +1,141 @@
+//go:build pgx
+// +build pgx
+
+package shifter
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+//PgxConn is the pgx connection or pool i.e. *pgx.Conn or *pgxpool.Pool
+type PgxConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+//pgxExecutor is the executor of pgx connection or pool
+type pgxExecutor struct {
+	ctx  context.Context
+	conn PgxConn
+}
+
+//pgxTx is the transaction of pgx connection
+type pgxTx struct {
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+//pgxSession is the session of pgx connection or connection acquired from pool
+type pgxSession struct {
+	pgxExecutor
+	release func()
+}
+
+//pgxResult is the result of sql executed by pgx
+type pgxResult struct {
+	tag pgconn.CommandTag
+}
+
+//NewPgxExecutor will return executor of pgx connection or pool.
+//It is built only with pgx build tag i.e. go build -tags pgx
+//so that go-pg users don't need pgx dependency
+func NewPgxExecutor(ctx context.Context, conn PgxConn) Executor {
+	return pgxExecutor{ctx: ctx, conn: conn}
+}
+
+//Exec will execute the sql
+func (e pgxExecutor) Exec(query string, params ...interface{}) (Result, error) {
+	return pgxExec(e.ctx, e.conn, query, params...)
+}
+
+//Query will execute the query and scan the rows in dest
+func (e pgxExecutor) Query(dest interface{}, query string, params ...interface{}) error {
+	return pgxQuery(e.ctx, e.conn, dest, query, params...)
+}
+
+//Begin will begin the transaction
+func (e pgxExecutor) Begin() (Tx, error) {
+	tx, err := e.conn.Begin(e.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pgxTx{ctx: e.ctx, tx: tx}, nil
+}
+
+//Session will acquire a connection if conn is pool which is released on close
+//pgx connection is itself used as session
+func (e pgxExecutor) Session() (Session, error) {
+	sess := pgxSession{pgxExecutor: e, release: func() {}}
+	if pool, ok := e.conn.(*pgxpool.Pool); ok {
+		conn, err := pool.Acquire(e.ctx)
+		if err != nil {
+			return nil, err
+		}
+		sess.conn, sess.release = conn, conn.Release
+	}
+	return sess, nil
+}
+
+//Close will release the connection acquired from pool
+func (s pgxSession) Close() error {
+	s.release()
+	return nil
+}
+
+//Exec will execute the sql in transaction
+func (t pgxTx) Exec(query string, params ...interface{}) (Result, error) {
+	return pgxExec(t.ctx, t.tx, query, params...)
+}
+
+//Query will execute the query in transaction and scan the rows in dest
+func (t pgxTx) Query(dest interface{}, query string, params ...interface{}) error {
+	return pgxQuery(t.ctx, t.tx, dest, query, params...)
+}
+
+//Commit will commit the transaction
+func (t pgxTx) Commit() error {
+	return t.tx.Commit(t.ctx)
+}
+
+//Rollback will rollback the transaction
+func (t pgxTx) Rollback() error {
+	return t.tx.Rollback(t.ctx)
+}
+
+//RowsAffected will return number of rows affected by the sql
+func (r pgxResult) RowsAffected() int {
+	return int(r.tag.RowsAffected())
+}
+
+//pgxExec will execute the sql using pgx
+//sql without args is executed using simple protocol so multiple statements are allowed
+func pgxExec(ctx context.Context, conn PgxConn, query string, params ...interface{}) (
+	res Result, err error) {
+
+	var tag pgconn.CommandTag
+	if tag, err = conn.Exec(ctx, formatQuery(query, params...)); err == nil {
+		res = pgxResult{tag: tag}
+	}
+	return
+}
+
+//pgxQuery will execute the query using pgx and scan the rows in dest
+func pgxQuery(ctx context.Context, conn PgxConn, dest interface{}, query string,
+	params ...interface{}) (err error) {
+
+	var rows pgx.Rows
+	if rows, err = conn.Query(ctx, formatQuery(query, params...)); err == nil {
+		defer rows.Close()
+		var columns []string
+		for _, field := range rows.FieldDescriptions() {
+			columns = append(columns, string(field.Name))
+		}
+		err = scanRows(dest, columns, rows)
+	}
+	return
+}
@@ -0,0 +1,272 @@
+package shifter
+
+import (
+	"sort"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/flaw"
+)
+
+//ChangeKind is the kind of schema change
+type ChangeKind string
+
+//change kinds
+const (
+	CreateSchemaChange     ChangeKind = "create schema"
+	CreateTableChange      ChangeKind = "create table"
+	DropTableChange        ChangeKind = "drop table"
+	CreateHistoryChange    ChangeKind = "create history table"
+	PostCreateChange       ChangeKind = "post table create sql"
+	AddColumnChange        ChangeKind = "add column"
+	DropColumnChange       ChangeKind = "drop column"
+	RenameColumnChange     ChangeKind = "rename column"
+	ModifyDataTypeChange   ChangeKind = "modify datatype"
+	ModifyDefaultChange    ChangeKind = "modify default"
+	ModifyNotNullChange    ChangeKind = "modify not null"
+	ValidateChange         ChangeKind = "validate constraint"
+	BackfillChange         ChangeKind = "backfill column"
+	AddConstraintChange    ChangeKind = "add constraint"
+	DropConstraintChange   ChangeKind = "drop constraint"
+	ModifyDeferrableChange ChangeKind = "modify deferrable"
+	AddUniqueKeyChange     ChangeKind = "add composite unique key"
+	DropUniqueKeyChange    ChangeKind = "drop composite unique key"
+	AddCompositeKeyChange  ChangeKind = "add composite key"
+	DropCompositeKeyChange ChangeKind = "drop composite key"
+	AddCheckChange         ChangeKind = "add check"
+	DropCheckChange        ChangeKind = "drop check"
+	CreateEnumChange       ChangeKind = "create enum"
+	AddEnumValueChange     ChangeKind = "add enum value"
+	DropEnumValueChange    ChangeKind = "drop enum value"
+	DropEnumChange         ChangeKind = "drop enum"
+	CreateIndexChange      ChangeKind = "create index"
+	DropIndexChange        ChangeKind = "drop index"
+	CreateTriggerChange    ChangeKind = "create trigger"
+	RevertChange           ChangeKind = "revert"
+)
+
+//Safety is the data safety level of a change
+type Safety string
+
+//change safety levels
+const (
+	SafeLevel        Safety = "safe"
+	LossyLevel       Safety = "lossy"
+	DestructiveLevel Safety = "destructive"
+)
+
+//destructiveKind are the change kinds which can destroy data
+var destructiveKind = map[ChangeKind]struct{}{
+	DropTableChange:     {},
+	DropColumnChange:    {},
+	DropEnumValueChange: {},
+	DropEnumChange:      {},
+}
+
+//Change is a single schema change planned/executed by shifter
+//NoTx change can't run in transaction i.e. create index concurrently
+//so it is executed after the transaction is committed
+type Change struct {
+	Kind        ChangeKind `json:"kind"`
+	Table       string     `json:"table"`
+	Column      string     `json:"column,omitempty"`
+	SQL         string     `json:"sql"`
+	DownSQL     string     `json:"down_sql,omitempty"`
+	Destructive bool       `json:"destructive"`
+	Safety      Safety     `json:"safety"`
+	NoTx        bool       `json:"no_tx,omitempty"`
+}
+
+//newChange will return change model
+func newChange(kind ChangeKind, tName, cName, sql string) (c Change) {
+	_, destructive := destructiveKind[kind]
+	c = Change{
+		Kind:        kind,
+		Table:       tName,
+		Column:      cName,
+		SQL:         sql,
+		Destructive: destructive,
+		Safety:      SafeLevel,
+	}
+	if destructive {
+		c.Safety = DestructiveLevel
+	}
+	return
+}
+
+// Plan will return the ordered list of changes which will be executed
+// to migrate the database to given table models. Nothing is executed in database.
+//
+// Parameters
+//  conn: postgresql connection
+//  models: struct pointers or strings (table names)
+// if models are not given then all the tables set in shifter using SetTableModels() are planned
+func (s *Shifter) Plan(conn *pg.DB, models ...interface{}) (changes []Change, err error) {
+	s.lock()
+	defer s.unlock()
+	var (
+		tx     Tx
+		tables []string
+	)
+	//changes are planned after the advisory lock is acquired
+	if err = s.advisoryLock(s.getExecutor(conn)); err == nil {
+		tables, err = s.getTableNames(models)
+	}
+	if err == nil {
+		if tx, err = s.begin(s.getExecutor(conn)); err == nil {
+			changes, err = s.plan(tx, tables)
+			//plan never changes anything so always rolling back
+			tx.Rollback()
+		} else {
+			err = flaw.TxError(err)
+		}
+	}
+	return
+}
+
+// PlanDrop will return the ordered list of changes which will be executed
+// to drop the given tables and their history tables. Nothing is executed in database.
+//
+// Parameters
+//  conn: postgresql connection
+//  cascade: if enable then tables are dropped with cascade
+//  models: struct pointers or strings (table names)
+// if models are not given then all the tables set in shifter using SetTableModels() are planned
+func (s *Shifter) PlanDrop(conn *pg.DB, cascade bool, models ...interface{}) (
+	changes []Change, err error) {
+
+	s.lock()
+	defer s.unlock()
+	var (
+		tx     Tx
+		tables []string
+	)
+	if tables, err = s.getTableNames(models); err == nil {
+		if tx, err = s.begin(s.getExecutor(conn)); err == nil {
+			changes, err = s.planDrop(tx, tables, cascade)
+			//plan never changes anything so always rolling back
+			tx.Rollback()
+		} else {
+			err = flaw.TxError(err)
+		}
+	}
+	return
+}
+
+//planDrop will record the drop changes of the given tables
+func (s *Shifter) planDrop(tx Tx, tables []string, cascade bool) (changes []Change, err error) {
+	s.run.dryRun = true
+	for _, tableName := range tables {
+		if err = s.dropTable(tx, tableName, cascade, true); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		changes = s.run.changes
+	}
+	return
+}
+
+// PlanOffline will return the ordered list of changes which will be executed
+// to migrate the database having the given catalog to given table models.
+// Database is not needed as existing schema is read from the catalog i.e. Snapshot.
+//
+// Parameters
+//  cat: catalog of existing database schema
+//  models: struct pointers or strings (table names)
+// if models are not given then all the tables set in shifter using SetTableModels() are planned
+func (s *Shifter) PlanOffline(cat Catalog, models ...interface{}) (changes []Change, err error) {
+	s.lock()
+	defer s.unlock()
+	var tables []string
+	if tables, err = s.getTableNames(models); err == nil {
+		s.run.catalog = cat
+		changes, err = s.plan(nil, tables)
+	}
+	return
+}
+
+//plan will record the changes of the given tables
+//online steps are recorded after the alter changes
+func (s *Shifter) plan(tx Tx, tables []string) (changes []Change, err error) {
+	s.run.dryRun = true
+	for _, tableName := range tables {
+		if err = s.planTable(tx, tableName); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		//online steps are only recorded in dry run so connection is not needed
+		if err = s.execOnline(nil); err == nil {
+			changes = s.run.changes
+		}
+	}
+	return
+}
+
+//planTable will record the changes of the given table
+func (s *Shifter) planTable(tx Tx, tableName string) (err error) {
+	if s.catalog(tx).TableExists(tableName) {
+		err = s.alterTable(tx, tableName, true)
+	} else if err = s.upsertAllEnum(tx, tableName); err == nil {
+		if err = s.execTableCreation(tx, tableName); err == nil {
+			if err = s.createIndex(tx, tableName, true); err == nil {
+				uk := s.getUKFromMethod(tableName)
+				_, err = s.addCompositeUK(tx, tableName, uk, true)
+			}
+		}
+	}
+	return
+}
+
+//getTableNames will return table names of given models
+//if models are not given then all table names set in shifter are returned in sorted order
+func (s *Shifter) getTableNames(models []interface{}) (tables []string, err error) {
+	if len(models) == 0 {
+		for tableName := range s.table {
+			tables = append(tables, tableName)
+		}
+		sort.Strings(tables)
+	} else {
+		for _, model := range models {
+			var tableName string
+			if tableName, err = s.getTableName(model); err != nil {
+				break
+			}
+			tables = append(tables, tableName)
+		}
+	}
+	return
+}
+
+//recordChange will record change in plan
+//same change which is already recorded is skipped
+func (s *Shifter) recordChange(c Change) {
+	for _, v := range s.run.changes {
+		if v.Kind == c.Kind && v.SQL == c.SQL {
+			return
+		}
+	}
+	s.run.changes = append(s.run.changes, c)
+}
+
+//exec will execute the change sql
+//in dry run the change is only recorded
+func (s *Shifter) exec(tx Tx, c Change) (err error) {
+	_, err = s.execResult(tx, c)
+	return
+}
+
+//execResult will execute the change sql and return the result
+//in dry run the change is recorded and result is nil
+func (s *Shifter) execResult(db Querier, c Change) (res Result, err error) {
+	if s.run.dryRun {
+		s.recordChange(c)
+	} else {
+		if res, err = s.execSQL(db, c); err == nil {
+			s.run.executed = append(s.run.executed, c)
+		} else {
+			err = getWrapError(c.Table, string(c.Kind), c.SQL, err)
+		}
+	}
+	return
+}
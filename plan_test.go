@@ -0,0 +1,77 @@
+package shifter
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mayur-tolexo/contour/adapter/psql"
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	if conn, err := psql.Conn(true); err == nil {
+		s := NewShifter()
+		addAllTables(s)
+		assert := assert.New(t)
+		changes, err := s.Plan(conn)
+		assert.NoError(err)
+		for _, c := range changes {
+			assert.NotEmpty(c.SQL)
+			assert.NotEmpty(c.Table)
+		}
+	}
+}
+
+func TestGetCreateTableSQL(t *testing.T) {
+	assert := assert.New(t)
+	sql, err := getCreateTableSQL(&db.TestAddress{})
+	assert.NoError(err)
+	assert.Contains(sql, "CREATE TABLE IF NOT EXISTS test_address")
+	assert.Contains(sql, `"created_by" int NOT NULL UNIQUE REFERENCES test_user(user_id)`)
+}
+
+func TestRecordChange(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	s.run.dryRun = true
+	err := s.exec(nil, newChange(DropColumnChange, "test_user", "name", "ALTER TABLE test_user DROP name;"))
+	assert.NoError(err)
+	err = s.exec(nil, newChange(DropColumnChange, "test_user", "name", "ALTER TABLE test_user DROP name;"))
+	assert.NoError(err)
+	isAlter, err := s.execByChoice(nil, newChange(AddColumnChange, "test_user", "age", "ALTER TABLE test_user ADD age int;"), false)
+	assert.NoError(err)
+	assert.True(isAlter)
+
+	if assert.Len(s.run.changes, 2) {
+		assert.Equal(DropColumnChange, s.run.changes[0].Kind)
+		assert.True(s.run.changes[0].Destructive)
+		assert.Equal(AddColumnChange, s.run.changes[1].Kind)
+		assert.False(s.run.changes[1].Destructive)
+	}
+}
+
+func TestPlanDrop(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "shifter")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s := NewShifter(&db.TestUser{}).SetLogPath(dir)
+	s.run.catalog = Snapshot{Tables: map[string]TableSnapshot{
+		"test_user":         {},
+		"test_user_history": {},
+	}}
+	changes, err := s.planDrop(nil, []string{"test_user"}, true)
+	assert.NoError(err)
+	//history table is dropped with the table
+	if assert.Len(changes, 2) {
+		assert.Equal("DROP TABLE IF EXISTS test_user CASCADE", changes[0].SQL)
+		assert.Equal("DROP TABLE IF EXISTS test_user_history CASCADE", changes[1].SQL)
+	}
+	//struct log is not written in plan
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(err)
+	assert.Empty(files)
+}
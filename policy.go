@@ -0,0 +1,200 @@
+package shifter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//safetyRank is the rank of safety level
+var safetyRank = map[Safety]int{
+	SafeLevel:        0,
+	LossyLevel:       1,
+	DestructiveLevel: 2,
+}
+
+//revertSafety are the safety level of reverse sql of change kinds
+//like reverse of add column will drop the column
+var revertSafety = map[ChangeKind]Safety{
+	CreateTableChange:    DestructiveLevel,
+	CreateHistoryChange:  DestructiveLevel,
+	AddColumnChange:      DestructiveLevel,
+	CreateEnumChange:     DestructiveLevel,
+	ModifyDataTypeChange: LossyLevel,
+}
+
+//integer and float data type rank to find narrowing
+var (
+	intRank = map[string]int{
+		"smallint": 1,
+		"integer":  2,
+		"bigint":   3,
+		"numeric":  4,
+	}
+	floatRank = map[string]int{
+		"real":             1,
+		"double precision": 2,
+	}
+	charType = map[string]struct{}{
+		"character":         {},
+		"character varying": {},
+		"text":              {},
+	}
+)
+
+//Policy decides which lossy and destructive changes are allowed to execute
+//safe changes are always allowed
+type Policy struct {
+	all    Safety
+	table  map[string]Safety
+	column map[string]Safety
+}
+
+//NewPolicy will return policy which refuses all lossy and destructive changes
+func NewPolicy() *Policy {
+	return &Policy{
+		all:    SafeLevel,
+		table:  make(map[string]Safety),
+		column: make(map[string]Safety),
+	}
+}
+
+//AllowAll will allow changes upto given safety level on all tables
+func (p *Policy) AllowAll(level Safety) *Policy {
+	p.all = level
+	return p
+}
+
+//AllowTable will allow changes upto given safety level on table
+func (p *Policy) AllowTable(tableName string, level Safety) *Policy {
+	p.table[tableName] = level
+	return p
+}
+
+//AllowColumn will allow changes upto given safety level on table column
+func (p *Policy) AllowColumn(tableName, column string, level Safety) *Policy {
+	p.column[tableName+"."+column] = level
+	return p
+}
+
+//Allowed will check the change is allowed by policy
+//if policy is nil then all changes are allowed
+func (p *Policy) Allowed(c Change) (allowed bool) {
+	if p == nil || c.Safety == SafeLevel || c.Safety == "" {
+		allowed = true
+	} else {
+		rank := safetyRank[c.Safety]
+		allowed = safetyRank[p.all] >= rank
+		//history table is allowed by the policy of its table
+		tableName := strings.TrimSuffix(c.Table, util.GetHistoryTableName(""))
+		for _, curTable := range []string{c.Table, tableName} {
+			if level, exists := p.table[curTable]; exists && safetyRank[level] >= rank {
+				allowed = true
+			}
+			if level, exists := p.column[curTable+"."+c.Column]; exists && safetyRank[level] >= rank {
+				allowed = true
+			}
+		}
+	}
+	return
+}
+
+//SetPolicy will set the policy which refuses lossy and destructive changes
+//unless allowed. By default there is no policy and all changes are allowed
+func (s *Shifter) SetPolicy(p *Policy) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = p
+	return s
+}
+
+//PolicyError is returned when changes are blocked by policy
+type PolicyError struct {
+	Blocked []Change
+}
+
+//Error will return blocked changes
+func (e *PolicyError) Error() string {
+	msg := fmt.Sprintf("%v change(s) blocked by policy:", len(e.Blocked))
+	for _, c := range e.Blocked {
+		name := c.Table
+		if c.Column != "" {
+			name += "." + c.Column
+		}
+		msg += fmt.Sprintf("\n%v %v %v\nSQL: %v", c.Safety, c.Kind, name,
+			strings.TrimSpace(c.SQL))
+	}
+	return msg
+}
+
+//getRevertSafety will return safety level of reverse sql of change
+func getRevertSafety(c Change) (level Safety) {
+	var exists bool
+	if level, exists = revertSafety[c.Kind]; exists == false {
+		level = SafeLevel
+	}
+	return
+}
+
+//isNarrowing will check data type change of column can lose data
+//like varchar(255) to varchar(100), numeric(12,4) to numeric(8,2) or bigint to integer
+func isNarrowing(tSchema, sSchema model.ColSchema) (narrowing bool) {
+	tType, sType := tSchema.DataType, sSchema.DataType
+	_, tChar := charType[tType]
+	_, sChar := charType[sType]
+	tInt, tIsInt := intRank[tType]
+	sInt, sIsInt := intRank[sType]
+	tFloat, tIsFloat := floatRank[tType]
+	sFloat, sIsFloat := floatRank[sType]
+
+	switch {
+	case sType == "text":
+		//every data type can be casted to text without loss
+	case tType == "numeric" && sType == "numeric":
+		tDigit, tScale := getNumericPrecision(tSchema)
+		sDigit, sScale := getNumericPrecision(sSchema)
+		narrowing = sDigit < tDigit || sScale < tScale
+	case tChar && sChar:
+		narrowing = getCharLen(sSchema) < getCharLen(tSchema)
+	case tIsInt && sIsInt:
+		narrowing = sInt < tInt
+	case tIsFloat && sIsFloat:
+		narrowing = sFloat < tFloat
+	case tType == sType:
+		narrowing = getCharLen(sSchema) < getCharLen(tSchema)
+	default:
+		//data type is changed to other type
+		narrowing = true
+	}
+	return
+}
+
+//getNumericPrecision will return digits before decimal point and scale of numeric column
+//i.e. numeric(12,4) has 8 digits and 4 scale
+//if precision is not defined then max int is returned for both
+func getNumericPrecision(schema model.ColSchema) (digit, scale int) {
+	digit, scale = int(^uint(0)>>1), int(^uint(0)>>1)
+	val := strings.Split(strings.Replace(schema.CharMaxLen, " ", "", -1), ",")
+	if precision, err := strconv.Atoi(val[0]); err == nil {
+		scale = 0
+		if len(val) > 1 {
+			scale, _ = strconv.Atoi(val[1])
+		}
+		digit = precision - scale
+	}
+	return
+}
+
+//getCharLen will return character max length of column
+//if length is not defined then max int is returned
+func getCharLen(schema model.ColSchema) (length int) {
+	var err error
+	if length, err = strconv.Atoi(schema.CharMaxLen); err != nil ||
+		schema.DataType == "text" {
+		length = int(^uint(0) >> 1)
+	}
+	return
+}
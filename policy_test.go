@@ -0,0 +1,83 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNarrowing(t *testing.T) {
+	assert := assert.New(t)
+	varchar := func(l string) model.ColSchema {
+		return model.ColSchema{DataType: "character varying", CharMaxLen: l}
+	}
+	dType := func(d string) model.ColSchema {
+		return model.ColSchema{DataType: d}
+	}
+	assert.True(isNarrowing(varchar("255"), varchar("100")))
+	assert.False(isNarrowing(varchar("100"), varchar("255")))
+	assert.True(isNarrowing(dType("text"), varchar("100")))
+	assert.False(isNarrowing(varchar("100"), dType("text")))
+	assert.True(isNarrowing(dType("bigint"), dType("integer")))
+	assert.False(isNarrowing(dType("integer"), dType("bigint")))
+	assert.True(isNarrowing(dType("double precision"), dType("real")))
+	assert.True(isNarrowing(dType("text"), dType("integer")))
+	assert.False(isNarrowing(dType("integer"), dType("text")))
+
+	numeric := func(p string) model.ColSchema {
+		return model.ColSchema{DataType: "numeric", CharMaxLen: p}
+	}
+	assert.True(isNarrowing(numeric("12,4"), numeric("8,2")))
+	assert.True(isNarrowing(numeric("12,4"), numeric("12,2")))
+	assert.True(isNarrowing(numeric("12,4"), numeric("10,4")))
+	assert.True(isNarrowing(numeric(""), numeric("12,4")))
+	assert.False(isNarrowing(numeric("8,2"), numeric("12,4")))
+	assert.False(isNarrowing(numeric("8,2"), numeric("")))
+	assert.False(isNarrowing(numeric("8"), numeric("10,2")))
+}
+
+func TestPolicy(t *testing.T) {
+	assert := assert.New(t)
+	drop := newChange(DropColumnChange, "test_user", "name", "ALTER TABLE test_user DROP name;")
+	add := newChange(AddColumnChange, "test_user", "age", "ALTER TABLE test_user ADD age int;")
+	lossy := newChange(ModifyDataTypeChange, "test_address", "city", "ALTER TABLE test_address ALTER COLUMN city TYPE varchar(10);")
+	lossy.Safety = LossyLevel
+
+	var nilPolicy *Policy
+	assert.True(nilPolicy.Allowed(drop))
+
+	p := NewPolicy()
+	assert.True(p.Allowed(add))
+	assert.False(p.Allowed(drop))
+	assert.False(p.Allowed(lossy))
+
+	p.AllowTable("test_address", LossyLevel)
+	assert.True(p.Allowed(lossy))
+	p.AllowColumn("test_user", "name", LossyLevel)
+	assert.False(p.Allowed(drop))
+	p.AllowColumn("test_user", "name", DestructiveLevel)
+	assert.True(p.Allowed(drop))
+
+	s := NewShifter().SetPolicy(NewPolicy())
+	isAlter, err := s.execByChoice(nil, drop, true)
+	assert.NoError(err)
+	assert.False(isAlter)
+	if assert.Len(s.run.blocked, 1) {
+		pErr := &PolicyError{Blocked: s.run.blocked}
+		assert.Contains(pErr.Error(), "destructive drop column test_user.name")
+	}
+
+	//drop table and its history table are checked by policy
+	s = NewShifter().SetPolicy(NewPolicy())
+	isAlter, err = s.execTableDrop(nil, "test_user", false, false)
+	assert.NoError(err)
+	assert.False(isAlter)
+	if assert.Len(s.run.blocked, 1) {
+		assert.Equal(DropTableChange, s.run.blocked[0].Kind)
+	}
+	history := newChange(DropTableChange, "test_user_history", "", "DROP TABLE test_user_history")
+	assert.False(p.Allowed(history))
+	p.AllowTable("test_user", DestructiveLevel)
+	assert.True(p.Allowed(history))
+}
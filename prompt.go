@@ -0,0 +1,136 @@
+package shifter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//Decision is the prompter decision on a change
+type Decision int
+
+//prompter decisions
+const (
+	//Skip will not execute the change and continue with next change
+	Skip Decision = iota
+	//Approve will execute the change
+	Approve
+	//Abort will stop the migration and rollback the executed changes
+	Abort
+)
+
+//ErrAborted is returned when prompter aborts the migration
+var ErrAborted = errors.New("migration aborted by prompter")
+
+//Prompter confirms each change before it is executed
+type Prompter interface {
+	Confirm(ctx context.Context, c Change) (Decision, error)
+}
+
+//PrompterFunc is a callback prompter
+type PrompterFunc func(ctx context.Context, c Change) (Decision, error)
+
+//Confirm will call the callback
+func (f PrompterFunc) Confirm(ctx context.Context, c Change) (Decision, error) {
+	return f(ctx, c)
+}
+
+//decisionPrompter always returns the same decision
+type decisionPrompter Decision
+
+//Confirm will return the decision
+func (d decisionPrompter) Confirm(ctx context.Context, c Change) (Decision, error) {
+	return Decision(d), nil
+}
+
+//destructivePrompter skips destructive changes and approves others
+type destructivePrompter struct{}
+
+//Confirm will skip destructive change
+func (destructivePrompter) Confirm(ctx context.Context, c Change) (d Decision, err error) {
+	d = Approve
+	if c.Destructive {
+		d = Skip
+	}
+	return
+}
+
+//stdinPrompter asks the choice from console
+type stdinPrompter struct{}
+
+//Confirm will ask user choice from console
+func (stdinPrompter) Confirm(ctx context.Context, c Change) (d Decision, err error) {
+	msg := fmt.Sprintf("Table: %v", c.Table)
+	if c.Column != "" {
+		msg += fmt.Sprintf(" Column: %v", c.Column)
+	}
+	msg += fmt.Sprintf(" Operation: %v\n%v", c.Kind, c.SQL)
+	if util.GetChoice(msg, false) == util.Yes {
+		d = Approve
+	}
+	return
+}
+
+//AlwaysYes will return prompter which approves every change
+func AlwaysYes() Prompter {
+	return decisionPrompter(Approve)
+}
+
+//AlwaysNo will return prompter which skips every change
+func AlwaysNo() Prompter {
+	return decisionPrompter(Skip)
+}
+
+//DenyDestructive will return prompter which skips destructive changes
+//like drop column and approves all other changes
+func DenyDestructive() Prompter {
+	return destructivePrompter{}
+}
+
+//Callback will return prompter which confirms change using the callback
+func Callback(fn func(ctx context.Context, c Change) (Decision, error)) Prompter {
+	return PrompterFunc(fn)
+}
+
+//StdinPrompter will return prompter which asks the choice from console
+//this is the default prompter
+func StdinPrompter() Prompter {
+	return stdinPrompter{}
+}
+
+//SetPrompter will set the prompter used to confirm changes when prompt is not skipped
+//default prompter asks the choice from console
+func (s *Shifter) SetPrompter(p Prompter) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompter = p
+	return s
+}
+
+//SetContext will set the context passed to prompter
+func (s *Shifter) SetContext(ctx context.Context) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx = ctx
+	return s
+}
+
+//confirm will return the prompter decision on change
+func (s *Shifter) confirm(c Change) (d Decision, err error) {
+	p, ctx := s.prompter, s.ctx
+	if p == nil {
+		p = StdinPrompter()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d, err = p.Confirm(ctx, c); err == nil && d == Abort {
+		err = ErrAborted
+	}
+	if err != nil {
+		err = getWrapError(c.Table, string(c.Kind), c.SQL, err)
+	}
+	return
+}
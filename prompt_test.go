@@ -0,0 +1,60 @@
+package shifter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrompter(t *testing.T) {
+	assert := assert.New(t)
+	add := newChange(AddColumnChange, "test_user", "age", "ALTER TABLE test_user ADD age int;")
+	drop := newChange(DropColumnChange, "test_user", "name", "ALTER TABLE test_user DROP name;")
+
+	s := NewShifter().SetPrompter(AlwaysYes())
+	d, err := s.confirm(drop)
+	assert.NoError(err)
+	assert.Equal(Approve, d)
+
+	s.SetPrompter(AlwaysNo())
+	d, err = s.confirm(add)
+	assert.NoError(err)
+	assert.Equal(Skip, d)
+
+	s.SetPrompter(DenyDestructive())
+	d, err = s.confirm(add)
+	assert.NoError(err)
+	assert.Equal(Approve, d)
+	d, err = s.confirm(drop)
+	assert.NoError(err)
+	assert.Equal(Skip, d)
+
+	var prompted Change
+	s.SetPrompter(Callback(func(ctx context.Context, c Change) (Decision, error) {
+		prompted = c
+		return Abort, nil
+	}))
+	_, err = s.confirm(drop)
+	assert.Error(err)
+	assert.Contains(err.Error(), ErrAborted.Error())
+	assert.Equal("name", prompted.Column)
+	assert.True(errors.Is(err, ErrAborted))
+	isAlter, err := s.execByChoice(nil, drop, false)
+	assert.True(errors.Is(err, ErrAborted))
+	assert.False(isAlter)
+	//aborted error is returned by the operation
+	s.SetExecutor(NewRecorder(nil))
+	err = s.runTx(s.getExecutor(nil), func(tx Tx) (err error) {
+		_, err = s.execByChoice(tx, drop, false)
+		return
+	})
+	assert.True(errors.Is(err, ErrAborted))
+
+	s.SetPrompter(Callback(func(ctx context.Context, c Change) (Decision, error) {
+		return Skip, errors.New("approval service down")
+	}))
+	_, err = s.confirm(add)
+	assert.Contains(err.Error(), "approval service down")
+}
@@ -0,0 +1,132 @@
+package shifter
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+var errRecordOnly = errors.New("recorder doesn't have executor to read the database")
+
+//Recorder is the executor which records the sql instead of executing it
+//so that the sql can be reviewed as a script.
+//Queries reading the database are executed using the given executor
+type Recorder struct {
+	reader Executor
+	mu     sync.Mutex
+	script []string
+}
+
+//recordTx records the sql of transaction
+//which is added in script on commit and discarded on rollback
+type recordTx struct {
+	r   *Recorder
+	sql []string
+}
+
+//recordSession records the sql executed outside transaction
+type recordSession struct {
+	*Recorder
+}
+
+//recordResult is the result of recorded sql
+type recordResult struct{}
+
+//NewRecorder will return executor which records the sql.
+//reader is used to read the database i.e. NewPgExecutor(conn)
+//if reader is nil then only the sql which doesn't read database can be recorded
+func NewRecorder(reader Executor) *Recorder {
+	return &Recorder{reader: reader}
+}
+
+//Exec will record the sql
+func (r *Recorder) Exec(query string, params ...interface{}) (Result, error) {
+	r.record(formatQuery(query, params...))
+	return recordResult{}, nil
+}
+
+//Query will execute the query using reader executor
+func (r *Recorder) Query(dest interface{}, query string, params ...interface{}) error {
+	if r.reader == nil {
+		return errRecordOnly
+	}
+	return r.reader.Query(dest, query, params...)
+}
+
+//Begin will begin the transaction which records the sql
+func (r *Recorder) Begin() (Tx, error) {
+	return &recordTx{r: r}, nil
+}
+
+//Session will return session which records the sql like recorder
+func (r *Recorder) Session() (Session, error) {
+	return recordSession{Recorder: r}, nil
+}
+
+//Close will do nothing as recorder has no connection
+func (recordSession) Close() error {
+	return nil
+}
+
+//Script will return the recorded sql
+//sql of committed transaction is wrapped in BEGIN and COMMIT
+func (r *Recorder) Script() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.script, "")
+}
+
+//Reset will remove the recorded sql
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.script = nil
+}
+
+//record will add sql in script
+func (r *Recorder) record(sql ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, curSQL := range sql {
+		r.script = append(r.script, getStatement(curSQL))
+	}
+}
+
+//Exec will record the sql in transaction
+func (t *recordTx) Exec(query string, params ...interface{}) (Result, error) {
+	t.sql = append(t.sql, formatQuery(query, params...))
+	return recordResult{}, nil
+}
+
+//Query will execute the query using reader executor
+func (t *recordTx) Query(dest interface{}, query string, params ...interface{}) error {
+	return t.r.Query(dest, query, params...)
+}
+
+//Commit will add the sql of transaction in script
+func (t *recordTx) Commit() error {
+	if len(t.sql) > 0 {
+		t.r.record(append(append([]string{"BEGIN"}, t.sql...), "COMMIT")...)
+	}
+	t.sql = nil
+	return nil
+}
+
+//Rollback will discard the sql of transaction
+func (t *recordTx) Rollback() error {
+	t.sql = nil
+	return nil
+}
+
+//RowsAffected will return zero as recorded sql is not executed
+func (recordResult) RowsAffected() int {
+	return 0
+}
+
+//getStatement will return sql terminated by semicolon and new line
+func getStatement(sql string) string {
+	if sql = strings.TrimSpace(sql); strings.HasSuffix(sql, ";") == false {
+		sql += ";"
+	}
+	return sql + "\n"
+}
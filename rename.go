@@ -0,0 +1,94 @@
+package shifter
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+)
+
+//renameCol will rename table column as defined in struct Renames() method
+//renamed column is moved in table schema so that it won't be dropped and added again
+func (s *Shifter) renameCol(tx Tx, tSchema, sSchema map[string]model.ColSchema,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	rename := s.getRenameFromMethod(getTableName(sSchema))
+	for _, newName := range getSortedRenameCol(rename) {
+		oldName := rename[newName]
+		tcSchema, oldExists := tSchema[oldName]
+		_, newExists := tSchema[newName]
+		_, structExists := sSchema[newName]
+
+		//renaming only if old column exists in table and new column in struct
+		if oldExists && newExists == false && structExists {
+			var curAlter bool
+			if curAlter, err = s.execRenameCol(tx, tcSchema.TableName, oldName, newName,
+				skipPrompt); err != nil {
+				break
+			}
+			if curAlter {
+				delete(tSchema, oldName)
+				tcSchema.ColumnName = newName
+				tSchema[newName] = tcSchema
+			}
+			isAlter = isAlter || curAlter
+		}
+	}
+	return
+}
+
+//execRenameCol will rename column of table and its history table
+func (s *Shifter) execRenameCol(tx Tx, tName, oldName, newName string,
+	skipPrompt bool) (isAlter bool, err error) {
+
+	sql := getRenameColSQL(tName, oldName, newName)
+	downSQL := getRenameColSQL(tName, newName, oldName)
+	//checking history table exists
+	if s.hisExists {
+		hName := util.GetHistoryTableName(tName)
+		sql += getRenameColSQL(hName, oldName, newName)
+		downSQL += getRenameColSQL(hName, newName, oldName)
+	}
+	//history alter sql end
+
+	c := newChange(RenameColumnChange, tName, newName, sql)
+	c.DownSQL = downSQL
+	isAlter, err = s.execByChoice(tx, c, skipPrompt)
+	return
+}
+
+//getRenameColSQL will return rename column sql
+func getRenameColSQL(tName, oldName, newName string) (sql string) {
+	sql = fmt.Sprintf("ALTER TABLE %v RENAME COLUMN %v TO %v;\n", tName, oldName, newName)
+	return
+}
+
+//getRenameFromMethod will return renamed columns of struct from Renames() method
+//returned map key is new column name and value is old column name
+func (s *Shifter) getRenameFromMethod(tableName string) (rename map[string]string) {
+	rename = make(map[string]string)
+	if dbModel, exists := s.table[tableName]; exists {
+		refObj := reflect.ValueOf(dbModel)
+		m := refObj.MethodByName("Renames")
+		if m.IsValid() {
+			out := m.Call([]reflect.Value{})
+			if len(out) > 0 && out[0].Kind() == reflect.Map {
+				if val, ok := out[0].Interface().(map[string]string); ok {
+					rename = val
+				}
+			}
+		}
+	}
+	return
+}
+
+//getSortedRenameCol will return new column names in sorted order
+func getSortedRenameCol(rename map[string]string) (cols []string) {
+	for k := range rename {
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+	return
+}
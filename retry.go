@@ -0,0 +1,193 @@
+package shifter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/flaw"
+)
+
+//lockNotAvailable is the postgresql error code of lock timeout
+const lockNotAvailable = "55P03"
+
+//RetryPolicy is the retry policy of sql failed with lock timeout
+type RetryPolicy struct {
+	//Attempts is the maximum attempts including the first one. Default is 1
+	Attempts int
+	//Backoff is the wait before first retry. It is doubled after every retry
+	Backoff time.Duration
+	//MaxBackoff is the maximum wait between retries. Default is no limit
+	MaxBackoff time.Duration
+}
+
+//getBackoff will return the wait before retry of failed attempt
+func (p RetryPolicy) getBackoff(attempt int) (wait time.Duration) {
+	wait = p.Backoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return
+}
+
+//SetLockTimeout will set lock_timeout of every transaction of shifter
+//so that sql waiting for a lock behind long running queries fails fast
+//instead of blocking all the queries on the table. Default is no timeout
+func (s *Shifter) SetLockTimeout(timeout time.Duration) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockTimeout = timeout
+	return s
+}
+
+//SetStatementTimeout will set statement_timeout of every transaction of shifter
+//Default is no timeout
+func (s *Shifter) SetStatementTimeout(timeout time.Duration) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stmtTimeout = timeout
+	return s
+}
+
+//SetRetry will set the retry policy of transaction failed with lock timeout.
+//Whole transaction is rolled back so that locks taken by its previous sql are released
+//and it is executed again after backoff. Changes are planned again in the new transaction
+func (s *Shifter) SetRetry(p RetryPolicy) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retry = p
+	return s
+}
+
+//getTimeoutSQL will return set lock and statement timeout sql of the transaction
+func (s *Shifter) getTimeoutSQL() (sql string) {
+	if s.lockTimeout > 0 {
+		sql += fmt.Sprintf("SET LOCAL lock_timeout = %v;\n", getMillisecond(s.lockTimeout))
+	}
+	if s.stmtTimeout > 0 {
+		sql += fmt.Sprintf("SET LOCAL statement_timeout = %v;\n", getMillisecond(s.stmtTimeout))
+	}
+	return
+}
+
+//getSessionTimeoutSQL will return set lock and statement timeout sql of the session
+//used for sql executed without transaction
+func (s *Shifter) getSessionTimeoutSQL() string {
+	return strings.Replace(s.getTimeoutSQL(), "SET LOCAL", "SET", -1)
+}
+
+//getSessionResetSQL will return reset sql of the timeouts set by getSessionTimeoutSQL
+//so that session returned to the pool doesn't have shifter timeouts
+func (s *Shifter) getSessionResetSQL() (sql string) {
+	if s.lockTimeout > 0 {
+		sql += "RESET lock_timeout;\n"
+	}
+	if s.stmtTimeout > 0 {
+		sql += "RESET statement_timeout;\n"
+	}
+	return
+}
+
+//getMillisecond will return duration in milliseconds
+func getMillisecond(d time.Duration) int64 {
+	return d.Nanoseconds() / int64(time.Millisecond)
+}
+
+//sqlStateError is the postgresql error of pgx and lib/pq having error code
+type sqlStateError interface {
+	SQLState() string
+}
+
+//isLockTimeout will check error is lock timeout error
+func isLockTimeout(err error) (timeout bool) {
+	if pgErr, ok := err.(pg.Error); ok {
+		timeout = pgErr.Field('C') == lockNotAvailable
+	} else if stateErr, ok := err.(sqlStateError); ok {
+		timeout = stateErr.SQLState() == lockNotAvailable
+	}
+	return
+}
+
+//execSQL will execute the sql and journal it
+//lock timeout is flagged so that the transaction is retried as per retry policy
+func (s *Shifter) execSQL(db Querier, c Change) (res Result, err error) {
+	sTime := time.Now()
+	res, err = db.Exec(c.SQL)
+	s.addJournalLog(c, time.Since(sTime), err)
+	if isLockTimeout(err) {
+		s.run.lockTimeout = true
+	}
+	return
+}
+
+//runTx will execute fn in a transaction which is committed if fn succeeds.
+//If sql of the transaction fails with lock timeout then the transaction is rolled back
+//and fn is executed again in a new transaction after backoff as per retry policy,
+//so locks taken by the previous sql are not held while waiting.
+//Start and result of every attempt is logged
+func (s *Shifter) runTx(conn Executor, fn func(tx Tx) error) (err error) {
+	attempts := s.getAttempts()
+	for attempt := 1; ; attempt++ {
+		var tx Tx
+		state := s.getTxState()
+		s.run.lockTimeout = false
+		fmt.Printf("Transaction attempt %v of %v started\n", attempt, attempts)
+		if tx, err = s.begin(conn); err == nil {
+			err = fn(tx)
+			timeout := err != nil && s.run.lockTimeout
+			err = s.commitIfNil(tx, err)
+			logAttempt(attempt, attempts, err)
+			if timeout && attempt < attempts {
+				wait := s.retry.getBackoff(attempt)
+				fmt.Printf("Lock timeout: retrying transaction in %v\n", wait)
+				s.setTxState(state)
+				if err = s.sleep(wait); err == nil {
+					continue
+				}
+			}
+		} else {
+			err = flaw.TxError(err)
+			logAttempt(attempt, attempts, err)
+		}
+		break
+	}
+	return
+}
+
+//getAttempts will return maximum attempts of transaction as per retry policy
+func (s *Shifter) getAttempts() (attempts int) {
+	if attempts = s.retry.Attempts; attempts < 1 {
+		attempts = 1
+	}
+	return
+}
+
+//logAttempt will log the result of transaction attempt
+func logAttempt(attempt, attempts int, err error) {
+	if err == nil {
+		fmt.Printf("Transaction attempt %v of %v succeeded\n", attempt, attempts)
+	} else {
+		fmt.Printf("Transaction attempt %v of %v failed: %v\n", attempt, attempts, err)
+	}
+}
+
+//sleep will wait for given duration or till shifter context is done
+func (s *Shifter) sleep(wait time.Duration) (err error) {
+	if s.ctx == nil {
+		time.Sleep(wait)
+	} else {
+		select {
+		case <-time.After(wait):
+		case <-s.ctx.Done():
+			err = s.ctx.Err()
+		}
+	}
+	return
+}
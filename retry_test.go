@@ -0,0 +1,94 @@
+package shifter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutSQL(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	assert.Equal("", s.getTimeoutSQL())
+	s.SetLockTimeout(2 * time.Second).SetStatementTimeout(time.Minute)
+	assert.Equal("SET LOCAL lock_timeout = 2000;\nSET LOCAL statement_timeout = 60000;\n",
+		s.getTimeoutSQL())
+	assert.False(isLockTimeout(nil))
+	assert.False(isLockTimeout(errCaptureOnly))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert := assert.New(t)
+	p := RetryPolicy{Attempts: 5, Backoff: 100 * time.Millisecond}
+	assert.Equal(100*time.Millisecond, p.getBackoff(1))
+	assert.Equal(200*time.Millisecond, p.getBackoff(2))
+	assert.Equal(800*time.Millisecond, p.getBackoff(4))
+	p.MaxBackoff = 300 * time.Millisecond
+	assert.Equal(300*time.Millisecond, p.getBackoff(3))
+	assert.Equal(300*time.Millisecond, p.getBackoff(10))
+}
+
+func TestSessionTimeoutSQL(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	assert.Equal("", s.getSessionTimeoutSQL())
+	assert.Equal("", s.getSessionResetSQL())
+	s.SetLockTimeout(2 * time.Second)
+	assert.Equal("SET lock_timeout = 2000;\n", s.getSessionTimeoutSQL())
+	assert.Equal("RESET lock_timeout;\n", s.getSessionResetSQL())
+}
+
+func TestNoTxSessionTimeout(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRecorder(nil)
+	s := NewShifter().SetExecutor(r).SetLockTimeout(time.Second)
+	c := newChange(CreateIndexChange, "test_user", "name",
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_name ON test_user USING btree (name);")
+	c.NoTx = true
+	err := s.execNoTx(s.getExecutor(nil), c)
+	assert.NoError(err)
+	assert.Equal("SET lock_timeout = 1000;\n"+getStatement(c.SQL)+"RESET lock_timeout;\n", r.Script())
+}
+
+func TestRetryTxState(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	s.run.tableCreated["test_user"] = struct{}{}
+	state := s.getTxState()
+	s.run.tableCreated["test_address"] = struct{}{}
+	s.run.newTables["test_address"] = struct{}{}
+	s.run.pendingFK = append(s.run.pendingFK, fkRef{})
+	s.setTxState(state)
+	assert.Equal(map[string]struct{}{"test_user": {}}, s.run.tableCreated)
+	assert.Empty(s.run.newTables)
+	assert.Empty(s.run.pendingFK)
+}
+
+func TestRunTxRetry(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter().SetRetry(RetryPolicy{Attempts: 3})
+	timeoutErr := errors.New("canceling statement due to lock timeout")
+	calls := 0
+	err := s.runTx(NewRecorder(nil), func(tx Tx) error {
+		if calls++; calls == 1 {
+			s.run.lockTimeout = true
+			return timeoutErr
+		}
+		return nil
+	})
+	assert.NoError(err)
+	assert.Equal(2, calls)
+
+	//attempts are not retried after the retry policy attempts
+	calls = 0
+	err = s.runTx(NewRecorder(nil), func(tx Tx) error {
+		calls++
+		s.run.lockTimeout = true
+		return timeoutErr
+	})
+	assert.Equal(timeoutErr, err)
+	assert.Equal(3, calls)
+	assert.Equal(1, NewShifter().getAttempts())
+}
@@ -0,0 +1,68 @@
+package shifter
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+)
+
+//AppliedChanges will return the changes committed in database by this shifter
+//in executed order. Each change contains its reverse sql in DownSQL
+func (s *Shifter) AppliedChanges() (changes []Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes = append(changes, s.applied...)
+	return
+}
+
+// Revert will execute the reverse sql of given changes in reverse order
+// in a single transaction. If any reverse sql fails then nothing is reverted.
+//
+// Parameters
+//  conn: postgresql connection
+//  changes: changes returned by AppliedChanges() or Plan()
+//  skipPrompt: if true then reverse sql are executed without prompt
+// changes which can't be reversed like drop table and trigger creation are skipped
+func (s *Shifter) Revert(conn *pg.DB, changes []Change, skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
+	var reverted map[Change]struct{}
+	err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
+		reverted = make(map[Change]struct{})
+		for i := len(changes) - 1; i >= 0; i-- {
+			var isAlter bool
+			c := changes[i]
+			if c.DownSQL == "" {
+				fmt.Printf("Irreversible %v skipped: %v\n", c.Kind, c.Table)
+				continue
+			}
+			rc := newChange(RevertChange, c.Table, c.Column, c.DownSQL)
+			rc.Safety = getRevertSafety(c)
+			rc.Destructive = (rc.Safety == DestructiveLevel)
+			if isAlter, err = s.execByChoice(tx, rc, getSP(skipPrompt)); err != nil {
+				break
+			}
+			if isAlter {
+				reverted[c] = struct{}{}
+			}
+		}
+		//reverse sql are not recorded as applied changes
+		s.run.executed = nil
+		return
+	})
+	if err == nil {
+		s.removeApplied(reverted)
+	}
+	return
+}
+
+//removeApplied will remove reverted changes from applied changes
+func (s *Shifter) removeApplied(reverted map[Change]struct{}) {
+	applied := s.applied[:0]
+	for _, c := range s.applied {
+		if _, exists := reverted[c]; exists == false {
+			applied = append(applied, c)
+		}
+	}
+	s.applied = applied
+}
@@ -0,0 +1,84 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownSQL(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	s.run.dryRun = true
+
+	tSchema := model.ColSchema{TableName: "test_user", ColumnName: "name",
+		DataType: "character varying", CharMaxLen: "50", IsNullable: "NO",
+		ColumnDefault: "'guest'::character varying"}
+	sSchema := tSchema
+	sSchema.CharMaxLen = "100"
+	sSchema.IsNullable = "YES"
+	sSchema.ColumnDefault = ""
+	sSchema.DefaultExists = true
+
+	_, err := s.modifyDataType(nil, tSchema, sSchema, true)
+	assert.NoError(err)
+	_, err = s.modifyNotNullConstraint(nil, tSchema, sSchema, true)
+	assert.NoError(err)
+	_, err = s.modifyDefault(nil, tSchema, sSchema, true)
+	assert.NoError(err)
+	_, err = s.addCol(nil, sSchema, true)
+	assert.NoError(err)
+
+	if assert.Len(s.run.changes, 4) {
+		assert.Equal("ALTER TABLE test_user ALTER COLUMN name DROP DEFAULT;\n"+
+			"ALTER TABLE test_user ALTER COLUMN name TYPE varchar(50) USING (name::text::varchar(50));\n"+
+			"ALTER TABLE test_user ALTER COLUMN name SET DEFAULT 'guest'::character varying;\n",
+			s.run.changes[0].DownSQL)
+		assert.Equal("ALTER TABLE test_user ALTER COLUMN name SET NOT NULL", s.run.changes[1].DownSQL)
+		assert.Equal("ALTER TABLE test_user ALTER COLUMN name SET DEFAULT 'guest'::character varying;\n",
+			s.run.changes[2].DownSQL)
+		assert.Equal("ALTER TABLE test_user DROP name;\n", s.run.changes[3].DownSQL)
+	}
+}
+
+func TestRemoveApplied(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	add := newChange(AddColumnChange, "test_user", "age", "ALTER TABLE test_user ADD age int;")
+	add.DownSQL = "ALTER TABLE test_user DROP age;"
+	trigger := newChange(CreateTriggerChange, "test_user", "", "CREATE TRIGGER ...")
+	s.applied = []Change{add, trigger}
+
+	s.removeApplied(map[Change]struct{}{add: {}})
+	if assert.Len(s.AppliedChanges(), 1) {
+		assert.Equal(CreateTriggerChange, s.AppliedChanges()[0].Kind)
+	}
+}
+
+func TestAddEnumValIrreversible(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	s.run.dryRun = true
+	_, err := s.addEnumVal(nil, "test_user", "status_type", "hold")
+	assert.NoError(err)
+	if assert.Len(s.run.changes, 1) {
+		assert.Equal(AddEnumValueChange, s.run.changes[0].Kind)
+		assert.Empty(s.run.changes[0].DownSQL)
+	}
+}
+
+func TestDropEnumDownSQL(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter(&db.TestAddress{})
+	s.run.dryRun = true
+	//database enum has drifted from struct
+	s.run.catalog = Snapshot{Enums: map[string][]string{"address_status": {"disable", "enable", "hold"}}}
+	_, err := s.dropEnum(nil, "test_address", "address_status", true)
+	assert.NoError(err)
+	if assert.Len(s.run.changes, 1) {
+		assert.Equal("CREATE type address_status AS ENUM('disable','enable','hold');",
+			s.run.changes[0].DownSQL)
+	}
+}
@@ -1,28 +1,44 @@
 package shifter
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/go-pg/pg"
-	"github.com/mayur-tolexo/flaw"
 	m "github.com/mayur-tolexo/pg-shifter/model"
 )
 
-var (
-	tableCreated = make(map[interface{}]bool)
-	enumCreated  = make(map[interface{}]struct{})
-)
-
 //Shifter model contains all the methods to migrate go struct to postgresql
+//public methods are safe to call from multiple goroutines
 type Shifter struct {
-	table     map[string]interface{}
-	enumList  map[string][]string
-	hisExists bool
-	logSQL    bool
-	verbose   bool
-	logPath   string
+	table       map[string]interface{}
+	enumList    map[string][]string
+	hisExists   bool
+	logSQL      bool
+	verbose     bool
+	logPath     string
+	journal     bool
+	applied     []Change
+	prompter    Prompter
+	policy      *Policy
+	schema      string
+	online      bool
+	batchSize   int
+	concurrent  bool
+	lockTimeout time.Duration
+	stmtTimeout time.Duration
+	retry       RetryPolicy
+	lockKey     string
+	lockWait    time.Duration
+	executor    Executor
+	migration   MigrationFormat
+	ctx         context.Context
+	mu          sync.Mutex
+	run         runState
 }
 
 func (s *Shifter) logMode(enable bool) {
@@ -34,6 +50,7 @@ func NewShifter(tables ...interface{}) *Shifter {
 	s := &Shifter{
 		table:    make(map[string]interface{}),
 		enumList: make(map[string][]string),
+		run:      newRunState(),
 	}
 	if len(tables) > 0 {
 		if err := s.SetTableModels(tables); err != nil {
@@ -47,12 +64,16 @@ func NewShifter(tables ...interface{}) *Shifter {
 //
 //deafult path is pwd/log/
 func (s *Shifter) SetLogPath(logPath string) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.logPath = logPath
 	return s
 }
 
 //Verbose will enable executed sql printing in console
 func (s *Shifter) Verbose(enable bool) *Shifter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.verbose = enable
 	return s
 }
@@ -64,17 +85,16 @@ func (s *Shifter) Verbose(enable bool) *Shifter {
 //  model: struct pointer or string (table name)
 // if model is table name then need to set shifter SetTableModel() before calling CreateTable()
 func (s *Shifter) CreateTable(conn *pg.DB, model interface{}) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			err = s.createTable(tx, tableName, true)
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
@@ -86,38 +106,39 @@ func (s *Shifter) CreateTable(conn *pg.DB, model interface{}) (err error) {
 //  model: struct pointer or string (table name)
 //  skipPrompt: bool (default false | if false then before execution sql it will prompt for confirmation)
 func (s *Shifter) AlterTable(conn *pg.DB, model interface{}, skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			err = s.alterTable(tx, tableName, getSP(skipPrompt))
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
 
 //DropTable will drop table if exists in database
+//if policy is set then drop is refused unless destructive change is allowed on the table
 // Parameters
 //  conn: postgresql connection
 //  model: struct pointer or string (table name)
 //  cascade: if enable then it will drop with cascade
-func (s *Shifter) DropTable(conn *pg.DB, model interface{}, cascade bool) (err error) {
+//  skipPrompt: bool (default false | if false then before dropping table and its history table it will prompt for confirmation)
+func (s *Shifter) DropTable(conn *pg.DB, model interface{}, cascade bool,
+	skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
-			err = s.dropTable(tx, tableName, cascade)
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
+			err = s.dropTable(tx, tableName, cascade, getSP(skipPrompt))
+			return
+		})
 	}
 	return
 }
@@ -130,17 +151,16 @@ func (s *Shifter) DropTable(conn *pg.DB, model interface{}, cascade bool) (err e
 //  enumName: enum which you want to create
 // if model is table name then need to set shifter SetTableModel() before calling CreateEnum()
 func (s *Shifter) CreateEnum(conn *pg.DB, model interface{}, enumName string) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			err = s.createEnumByName(tx, tableName, enumName)
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
@@ -152,21 +172,20 @@ func (s *Shifter) CreateEnum(conn *pg.DB, model interface{}, enumName string) (e
 //  model: struct pointer or string (table name)
 // if model is table name then need to set shifter SetTableModel() before calling CreateAllEnum()
 func (s *Shifter) CreateAllEnum(conn *pg.DB, model interface{}) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			for enumName := range s.getEnumFromMethod(tableName) {
 				if err = s.createEnumByName(tx, tableName, enumName); err != nil {
 					break
 				}
 			}
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
@@ -179,17 +198,16 @@ func (s *Shifter) CreateAllEnum(conn *pg.DB, model interface{}) (err error) {
 //  enumName: enum which you want to upsert
 // if model is table name then need to set shifter SetTableModel() before calling UpsertEnum()
 func (s *Shifter) UpsertEnum(conn *pg.DB, model interface{}, enumName string) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			err = s.upsertEnum(tx, tableName, enumName)
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
@@ -201,17 +219,16 @@ func (s *Shifter) UpsertEnum(conn *pg.DB, model interface{}, enumName string) (e
 //  model: struct pointer or string (table name)
 // if model is table name then need to set shifter SetTableModel() before calling UpsertAllEnum()
 func (s *Shifter) UpsertAllEnum(conn *pg.DB, model interface{}) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			err = s.upsertAllEnum(tx, tableName)
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
@@ -224,17 +241,16 @@ func (s *Shifter) UpsertAllEnum(conn *pg.DB, model interface{}) (err error) {
 //  skipPrompt: bool (default false | if false then before execution sql it will prompt for confirmation)
 // if model is table name then need to set shifter SetTableModel() before calling DropAllEnum()
 func (s *Shifter) DropAllEnum(conn *pg.DB, model interface{}, skipPrompt bool) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			err = s.dropAllEnum(tx, tableName, skipPrompt)
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
@@ -247,17 +263,41 @@ func (s *Shifter) DropAllEnum(conn *pg.DB, model interface{}, skipPrompt bool) (
 //  skipPrompt: bool (default false | if false then before execution sql it will prompt for confirmation)
 // if model is table name then need to set shifter SetTableModel() before calling CreateAllIndex()
 func (s *Shifter) CreateAllIndex(conn *pg.DB, model interface{}, skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
 			err = s.createIndex(tx, tableName, getSP(skipPrompt))
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
+	}
+	return
+}
+
+// UpsertAllIndex will create/recreate/drop index of the given table.
+//
+// Parameters
+//  conn: postgresql connection
+//  model: struct pointer or string (table name)
+//  skipPrompt: bool (default false | if false then before execution sql it will prompt for confirmation)
+// If index columns or type are modified in struct Index() method then index will be recreated.
+// If index exists in table but doesn't exists in struct Index() method then that will be dropped.
+func (s *Shifter) UpsertAllIndex(conn *pg.DB, model interface{}, skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
+	var (
+		tableName string
+	)
+	if tableName, err = s.getTableName(model); err == nil {
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
+			if _, err = s.dropInvalidIndex(tx, tableName, getSP(skipPrompt)); err == nil {
+				_, _, err = s.modifyIndex(tx, tableName, getSP(skipPrompt))
+			}
+			return
+		})
 	}
 	return
 }
@@ -270,18 +310,19 @@ func (s *Shifter) CreateAllIndex(conn *pg.DB, model interface{}, skipPrompt ...b
 //  skipPrompt: bool (default false | if false then before execution sql it will prompt for confirmation)
 // if model is table name then need to set shifter SetTableModel() before calling CreateAllUniqueKey()
 func (s *Shifter) CreateAllUniqueKey(conn *pg.DB, model interface{}, skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
-			uk := s.getUKFromMethod(tableName)
-			_, err = addCompositeUK(tx, tableName, uk, getSP(skipPrompt))
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
+			if _, err = s.dropInvalidIndex(tx, tableName, getSP(skipPrompt)); err == nil {
+				uk := s.getUKFromMethod(tableName)
+				_, err = s.addCompositeUK(tx, tableName, uk, getSP(skipPrompt))
+			}
+			return
+		})
 	}
 	return
 }
@@ -297,113 +338,145 @@ func (s *Shifter) CreateAllUniqueKey(conn *pg.DB, model interface{}, skipPrompt
 // If composite unique key exists in table but doesn't exists in struct UniqueKey method
 // then that will be dropped.
 func (s *Shifter) UpsertAllUniqueKey(conn *pg.DB, model interface{}, skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
 	var (
-		tx        *pg.Tx
 		tUK       []m.UKSchema
 		tableName string
 	)
 	if tableName, err = s.getTableName(model); err == nil {
-		if tx, err = conn.Begin(); err == nil {
-
-			if tUK, err = getDBCompositeUniqueKey(tx, tableName); err == nil {
-				sUK := s.getUKFromMethod(tableName)
-				if len(tUK) > 0 || len(sUK) > 0 {
-					if _, err = dropCompositeUK(tx, tableName, tUK, sUK, getSP(skipPrompt)); err == nil {
-						_, err = addCompositeUK(tx, tableName, sUK, getSP(skipPrompt))
+		err = s.runTx(s.getExecutor(conn), func(tx Tx) (err error) {
+			if _, err = s.dropInvalidIndex(tx, tableName, getSP(skipPrompt)); err == nil {
+				if tUK, err = s.catalog(tx).CompositeUniqueKey(tableName); err == nil {
+					sUK := s.getUKFromMethod(tableName)
+					if len(tUK) > 0 || len(sUK) > 0 {
+						if _, err = s.dropCompositeUK(tx, tableName, tUK, sUK, getSP(skipPrompt)); err == nil {
+							_, err = s.addCompositeUK(tx, tableName, sUK, getSP(skipPrompt))
+						}
 					}
 				}
 			}
-
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-		}
+			return
+		})
 	}
 	return
 }
 
-//CreateAllTable will create all tables
+//CreateAllTable will create all tables in dependency order
+//foreign keys which form a dependency cycle are added after all tables are created
 //before calling it you need to set the table model in shifter using SetTableModels()
 func (s *Shifter) CreateAllTable(conn *pg.DB) (err error) {
-	for tableName := range s.table {
-		var tx *pg.Tx
-		if tx, err = conn.Begin(); err == nil {
-			if err = s.createTable(tx, tableName, true); err == nil {
+	s.lock()
+	defer s.unlock()
+	if err = s.advisoryLock(s.getExecutor(conn)); err == nil {
+		err = s.createAllTable(s.getExecutor(conn))
+	}
+	return
+}
+
+//createAllTable will create all tables in dependency order
+func (s *Shifter) createAllTable(conn Executor) (err error) {
+	dep := s.getDependency()
+	printCycles(dep)
+	s.run.deferredFK = dep.deferred
+	for _, tableName := range dep.order {
+		if err = s.runTx(conn, func(tx Tx) (err error) {
+			if err = s.createTable(tx, tableName, false); err == nil {
 				if err = s.createIndex(tx, tableName, true); err == nil {
 					uk := s.getUKFromMethod(tableName)
-					_, err = addCompositeUK(tx, tableName, uk, true)
+					_, err = s.addCompositeUK(tx, tableName, uk, true)
 				}
 			}
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
+			return
+		}); err != nil {
 			break
 		}
 	}
+	if err == nil && len(s.run.pendingFK) > 0 {
+		err = s.runTx(conn, func(tx Tx) error {
+			return s.addPendingFK(tx)
+		})
+	}
+	s.run.deferredFK, s.run.pendingFK = nil, nil
 	return
 }
 
 //AlterAllTable will alter all tables
 //before calling it you need to set the table model in shifter using SetTableModels()
 func (s *Shifter) AlterAllTable(conn *pg.DB, skipPromt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
 
-	s.Debug(conn)
-	var tx *pg.Tx
-	if tx, err = conn.Begin(); err == nil {
-		for tableName := range s.table {
-			if err = s.alterTable(tx, tableName, getSP(skipPromt)); err != nil {
-				break
-			}
-		}
-		commitIfNil(tx, err)
-	} else {
-		err = flaw.TxError(err)
+	if err = s.advisoryLock(s.getExecutor(conn)); err == nil {
+		err = s.alterAllTable(s.getExecutor(conn), getSP(skipPromt))
 	}
 	return
 }
 
-//DropAllTable will drop all tables
+//DropAllTable will drop all tables in reverse dependency order
+//foreign keys which form a dependency cycle are dropped first
 //before calling it you need to set the table model in shifter using SetTableModels()
-func (s *Shifter) DropAllTable(conn *pg.DB, cascade bool) (err error) {
-	for tableName := range s.table {
-		var tx *pg.Tx
-		if tx, err = conn.Begin(); err == nil {
-			if err = s.dropTable(tx, tableName, cascade); err != nil {
-				break
+//if skipPrompt is false then before dropping each table it will prompt for confirmation
+func (s *Shifter) DropAllTable(conn *pg.DB, cascade bool, skipPrompt ...bool) (err error) {
+	s.lock()
+	defer s.unlock()
+	if err = s.advisoryLock(s.getExecutor(conn)); err == nil {
+		err = s.dropAllTable(s.getExecutor(conn), cascade, getSP(skipPrompt))
+	}
+	return
+}
+
+//dropAllTable will drop all tables in reverse dependency order
+func (s *Shifter) dropAllTable(conn Executor, cascade, skipPrompt bool) (err error) {
+	dep := s.getDependency()
+	err = s.runTx(conn, func(tx Tx) (err error) {
+		if err = s.dropDeferredFK(tx, dep.deferred); err == nil {
+			for i := len(dep.order) - 1; i >= 0; i-- {
+				if err = s.dropTable(tx, dep.order[i], cascade, skipPrompt); err != nil {
+					break
+				}
 			}
-			commitIfNil(tx, err)
-		} else {
-			err = flaw.TxError(err)
-			break
 		}
-	}
+		return
+	})
 	return
 }
 
 //CreateStruct will create golang structure from postgresql table
 func (s *Shifter) CreateStruct(conn *pg.DB, tableName string,
 	filePath string) (err error) {
+	s.lock()
+	defer s.unlock()
+	return s.createStruct(s.getExecutor(conn), tableName, filePath)
+}
+
+//createStruct will create golang structure from postgresql table
+func (s *Shifter) createStruct(conn Executor, tableName string,
+	filePath string) (err error) {
 
 	var (
-		tx      *pg.Tx
+		tx      Tx
 		tUK     []m.UKSchema
+		tKey    []m.KeySchema
 		idx     []m.Index
 		tSchema map[string]m.ColSchema
 	)
-	if tx, err = conn.Begin(); err == nil {
+	if tx, err = s.begin(conn); err == nil {
 
 		if tSchema, err = s.getTableSchema(tx, tableName); err == nil {
-			if tUK, err = getDBCompositeUniqueKey(tx, tableName); err == nil {
-				if idx, err = getDBIndex(tx, tableName); err == nil {
-					curLogPath := s.logPath
-					s.logPath = filePath
-					err = s.createAlterStructLog(tSchema, tUK, idx, false)
-					s.logPath = curLogPath
+			if tUK, err = s.catalog(tx).CompositeUniqueKey(tableName); err == nil {
+				if tKey, err = s.catalog(tx).CompositeKey(tableName); err == nil {
+					if idx, err = s.catalog(tx).Index(tableName); err == nil {
+						curLogPath := s.logPath
+						s.logPath = filePath
+						err = s.createAlterStructLog(tSchema, tUK, tKey, idx, false)
+						s.logPath = curLogPath
+					}
 				}
 			}
 		}
 
-		commitIfNil(tx, err)
+		err = s.commitIfNil(tx, err)
 	}
 	return
 }
@@ -413,8 +486,10 @@ func (s *Shifter) CreateStruct(conn *pg.DB, tableName string,
 //before calling it you need to set all the table models in shifter using SetTableModels()
 func (s *Shifter) CreateStructFromStruct(conn *pg.DB, filePath string) (
 	err error) {
+	s.lock()
+	defer s.unlock()
 	for tName := range s.table {
-		if err = s.CreateStruct(conn, tName, filePath); err != nil {
+		if err = s.createStruct(s.getExecutor(conn), tName, filePath); err != nil {
 			break
 		} else if s.verbose {
 			fmt.Print("Struct created: ")
@@ -428,15 +503,10 @@ func (s *Shifter) CreateStructFromStruct(conn *pg.DB, filePath string) (
 //CreateTrigger will create triggers mentioned on struct
 //before calling it you need to set the table model in shifter using SetTableModel()
 func (s *Shifter) CreateTrigger(conn *pg.DB, tableName string) (err error) {
-	var tx *pg.Tx
-	s.Debug(conn)
-	if tx, err = conn.Begin(); err == nil {
-		err = s.createTrigger(tx, tableName)
-		if err == nil {
-			tx.Commit()
-		} else {
-			tx.Rollback()
-		}
-	}
+	s.lock()
+	defer s.unlock()
+	err = s.runTx(s.getExecutor(conn), func(tx Tx) error {
+		return s.createTrigger(tx, tableName)
+	})
 	return
 }
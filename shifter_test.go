@@ -166,6 +166,21 @@ func TestAlterTable(t *testing.T) {
 	}
 }
 
+func TestHistory(t *testing.T) {
+	if conn, err := psql.Conn(true); err == nil {
+		s := NewShifter().Journal(true)
+		assert := assert.New(t)
+		err = s.AlterTable(conn, &db.TestAddress{}, true)
+		assert.NoError(err)
+		logs, err := s.History(conn, "test_address")
+		assert.NoError(err)
+		for _, log := range logs {
+			assert.Equal("test_address", log.TableName)
+			assert.NotEmpty(log.Outcome)
+		}
+	}
+}
+
 func TestCreateTrigger(t *testing.T) {
 
 	if conn, err := psql.Conn(true); err == nil {
@@ -182,11 +197,11 @@ func TestDropTable(t *testing.T) {
 
 	if conn, err := psql.Conn(true); err == nil {
 		s := NewShifter()
-		err := s.DropTable(conn, &db.TestAddress{}, true)
+		err := s.DropTable(conn, &db.TestAddress{}, true, true)
 		assert := assert.New(t)
 		assert.NoError(err)
 
-		err = s.DropTable(conn, "test_user", true)
+		err = s.DropTable(conn, "test_user", true, true)
 		assert.NoError(err)
 	}
 }
@@ -196,7 +211,7 @@ func TestDropAllTable(t *testing.T) {
 	if conn, err := psql.Conn(true); err == nil {
 		s := NewShifter()
 		addAllTables(s)
-		err := s.DropAllTable(conn, true)
+		err := s.DropAllTable(conn, true, true)
 		assert := assert.New(t)
 		assert.NoError(err)
 	}
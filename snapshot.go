@@ -0,0 +1,238 @@
+package shifter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/mayur-tolexo/flaw"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//Snapshot is the database schema of tables dumped in a json/yaml file.
+//It implements Catalog so that changes can be planned without database
+type Snapshot struct {
+	Tables map[string]TableSnapshot `json:"tables" yaml:"tables"`
+	Enums  map[string][]string      `json:"enums,omitempty" yaml:"enums,omitempty"`
+	//VolatileFunctions are the volatile functions of database
+	//so that volatility of any function called in column default is known
+	VolatileFunctions []string `json:"volatile_functions,omitempty" yaml:"volatile_functions,omitempty"`
+}
+
+//TableSnapshot is the database schema of a table
+type TableSnapshot struct {
+	Columns             []model.ColSchema   `json:"columns" yaml:"columns"`
+	Constraints         []model.ColSchema   `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	CompositeKeys       []model.KeySchema   `json:"composite_keys,omitempty" yaml:"composite_keys,omitempty"`
+	CompositeUniqueKeys []model.UKSchema    `json:"composite_unique_keys,omitempty" yaml:"composite_unique_keys,omitempty"`
+	Checks              []model.CheckSchema `json:"checks,omitempty" yaml:"checks,omitempty"`
+	Indexes             []model.Index       `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+	InvalidIndexes      []string            `json:"invalid_indexes,omitempty" yaml:"invalid_indexes,omitempty"`
+	Triggers            []string            `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+}
+
+// Snapshot will dump the database schema of tables, their history tables and enums.
+// Snapshot can be written in a file and used to plan the changes without database.
+//
+// Parameters
+//  conn: postgresql connection
+//  models: struct pointers or strings (table names)
+// if models are not given then all the tables set in shifter using SetTableModels() are dumped
+func (s *Shifter) Snapshot(conn *pg.DB, models ...interface{}) (snap Snapshot, err error) {
+	s.lock()
+	defer s.unlock()
+	var (
+		tx     Tx
+		tables []string
+	)
+	snap = Snapshot{Tables: make(map[string]TableSnapshot), Enums: make(map[string][]string)}
+	if tables, err = s.getTableNames(models); err == nil {
+		if tx, err = s.begin(s.getExecutor(conn)); err == nil {
+			if _, err = tx.Exec("SET TRANSACTION READ ONLY"); err == nil {
+				for _, tableName := range tables {
+					if err = s.snapshotTable(tx, tableName, &snap); err != nil {
+						break
+					}
+				}
+			}
+			if err == nil {
+				snap.VolatileFunctions, err = getDBVolatileFunction(tx)
+			}
+			tx.Rollback()
+		} else {
+			err = flaw.TxError(err)
+		}
+	}
+	return
+}
+
+//snapshotTable will add database schema of table, its history table
+//and enums in snapshot
+func (s *Shifter) snapshotTable(tx Tx, tableName string, snap *Snapshot) (err error) {
+	live := liveCatalog{tx: tx}
+	tables := []string{tableName}
+	if s.isSkip(tableName) == false {
+		tables = append(tables, util.GetHistoryTableName(tableName))
+	}
+	for _, curTable := range tables {
+		if live.TableExists(curTable) {
+			var tSnap TableSnapshot
+			if tSnap, err = getTableSnapshot(live, curTable); err != nil {
+				break
+			}
+			snap.Tables[curTable] = tSnap
+		}
+	}
+	for _, refFeild := range util.GetStructField(s.table[tableName]) {
+		fType := util.FieldType(refFeild)
+		typeName := s.getEnumTypeName(tableName, fType)
+		if err == nil && s.isEnum(tableName, fType) && live.EnumExists(typeName) {
+			snap.Enums[typeName], err = live.EnumValue(typeName)
+		}
+	}
+	return
+}
+
+//getTableSnapshot will return database schema of table from catalog
+func getTableSnapshot(cat Catalog, tableName string) (tSnap TableSnapshot, err error) {
+	if tSnap.Columns, err = cat.ColumnSchema(tableName); err == nil {
+		sort.Slice(tSnap.Columns, func(i, j int) bool {
+			return tSnap.Columns[i].Position < tSnap.Columns[j].Position
+		})
+		if tSnap.Constraints, err = cat.Constraint(tableName); err == nil {
+			if tSnap.CompositeKeys, err = cat.CompositeKey(tableName); err == nil {
+				tSnap.CompositeUniqueKeys, err = cat.CompositeUniqueKey(tableName)
+			}
+		}
+	}
+	if err == nil {
+		if tSnap.Checks, err = cat.Check(tableName); err == nil {
+			if tSnap.Indexes, err = cat.Index(tableName); err == nil {
+				if tSnap.InvalidIndexes, err = cat.InvalidIndex(tableName); err == nil {
+					tSnap.Triggers, err = cat.Trigger(tableName)
+				}
+			}
+		}
+	}
+	return
+}
+
+//LoadSnapshot will load snapshot from json or yaml file
+//file having .json extension is read as json else as yaml
+func LoadSnapshot(path string) (snap Snapshot, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err == nil {
+		if isJSONFile(path) {
+			err = json.Unmarshal(data, &snap)
+		} else {
+			err = yaml.Unmarshal(data, &snap)
+		}
+	}
+	return
+}
+
+//Write will write snapshot in json or yaml file
+//file having .json extension is written as json else as yaml
+func (snap Snapshot) Write(path string) (err error) {
+	var data []byte
+	if isJSONFile(path) {
+		data, err = json.MarshalIndent(snap, "", "  ")
+	} else {
+		data, err = yaml.Marshal(snap)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(path, data, 0644)
+	}
+	return
+}
+
+//isJSONFile will check file has json extension
+func isJSONFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".json"
+}
+
+//isVolatile will check function is volatile in snapshot
+func (snap Snapshot) isVolatile(funcName string) bool {
+	for _, name := range snap.VolatileFunctions {
+		if name == funcName {
+			return true
+		}
+	}
+	return false
+}
+
+//TableExists will check table exists in snapshot
+func (snap Snapshot) TableExists(tableName string) (exists bool) {
+	_, exists = snap.Tables[tableName]
+	return
+}
+
+//ColumnSchema will return columns of table from snapshot
+func (snap Snapshot) ColumnSchema(tableName string) ([]model.ColSchema, error) {
+	return snap.Tables[tableName].Columns, nil
+}
+
+//Constraint will return single column constraints of table from snapshot
+func (snap Snapshot) Constraint(tableName string) ([]model.ColSchema, error) {
+	return snap.Tables[tableName].Constraints, nil
+}
+
+//CompositeKey will return composite primary and foreign keys of table from snapshot
+func (snap Snapshot) CompositeKey(tableName string) (key []model.KeySchema, err error) {
+	key = append(key, snap.Tables[tableName].CompositeKeys...)
+	for i := range key {
+		key[i].TableName = tableName
+	}
+	return
+}
+
+//CompositeUniqueKey will return composite unique keys of table from snapshot
+func (snap Snapshot) CompositeUniqueKey(tableName string) ([]model.UKSchema, error) {
+	return snap.Tables[tableName].CompositeUniqueKeys, nil
+}
+
+//Check will return check constraints of table from snapshot
+func (snap Snapshot) Check(tableName string) ([]model.CheckSchema, error) {
+	return snap.Tables[tableName].Checks, nil
+}
+
+//Index will return indexes of table from snapshot
+func (snap Snapshot) Index(tableName string) ([]model.Index, error) {
+	return snap.Tables[tableName].Indexes, nil
+}
+
+//InvalidIndex will return invalid indexes of table from snapshot
+func (snap Snapshot) InvalidIndex(tableName string) ([]string, error) {
+	return snap.Tables[tableName].InvalidIndexes, nil
+}
+
+//Trigger will return trigger names of table from snapshot
+func (snap Snapshot) Trigger(tableName string) ([]string, error) {
+	return snap.Tables[tableName].Triggers, nil
+}
+
+//EnumExists will check enum type exists in snapshot
+func (snap Snapshot) EnumExists(enumName string) (exists bool) {
+	_, exists = snap.Enums[enumName]
+	return
+}
+
+//EnumValue will return values of enum type from snapshot
+func (snap Snapshot) EnumValue(enumName string) ([]string, error) {
+	return snap.Enums[enumName], nil
+}
+
+//VolatileFunction will check any of the given functions is volatile in snapshot
+func (snap Snapshot) VolatileFunction(funcs []string) (volatile bool, err error) {
+	for _, name := range funcs {
+		if volatile = snap.isVolatile(name); volatile {
+			break
+		}
+	}
+	return
+}
@@ -0,0 +1,106 @@
+package shifter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mayur-tolexo/contour/adapter/psql"
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	if conn, err := psql.Conn(true); err == nil {
+		s := NewShifter()
+		addAllTables(s)
+		assert := assert.New(t)
+		snap, err := s.Snapshot(conn)
+		assert.NoError(err)
+		//volatility of functions not used in struct default is known
+		assert.Contains(snap.VolatileFunctions, "clock_timestamp")
+		assert.NotContains(snap.VolatileFunctions, "now")
+		_, err = s.PlanOffline(snap)
+		assert.NoError(err)
+	}
+}
+
+func TestPlanOffline(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	addAllTables(s)
+
+	//table missing in snapshot is created
+	changes, err := s.PlanOffline(Snapshot{}, &db.TestAddress{})
+	assert.NoError(err)
+	if assert.NotEmpty(changes) {
+		assert.Equal(CreateEnumChange, changes[0].Kind)
+		assert.Contains(changes[1].SQL, "CREATE TABLE IF NOT EXISTS test_address")
+	}
+
+	//column missing in snapshot table is added
+	var columns []model.ColSchema
+	for _, col := range s.getStructSchema("test_address") {
+		if col.ColumnName != "city" {
+			columns = append(columns, col)
+		}
+	}
+	snap := Snapshot{
+		Tables: map[string]TableSnapshot{
+			"test_address":         {Columns: columns},
+			"test_address_history": {},
+		},
+		Enums: map[string][]string{"address_status": {"enable", "disable"}},
+	}
+	changes, err = s.PlanOffline(snap, "test_address")
+	assert.NoError(err)
+	found := false
+	for _, c := range changes {
+		assert.NotEqual(CreateTableChange, c.Kind)
+		if c.Kind == AddColumnChange && c.Column == "city" {
+			found = true
+		}
+	}
+	assert.True(found)
+}
+
+func TestSnapshotFile(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "shifter")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	snap := Snapshot{
+		Tables: map[string]TableSnapshot{
+			"test_user": {
+				Columns: []model.ColSchema{{ColumnName: "user_id", DataType: "integer",
+					IsNullable: "NO", Position: 1}},
+				CompositeKeys: []model.KeySchema{{ConstraintName: "pk", Columns: "a,b"}},
+				Indexes:       []model.Index{{IdxName: "idx_user_name", IType: "btree", Columns: "name"}},
+			},
+		},
+		Enums:             map[string][]string{"user_yesno_type": {"yes", "no"}},
+		VolatileFunctions: []string{"random"},
+	}
+	for _, file := range []string{"schema.json", "schema.yaml"} {
+		path := filepath.Join(dir, file)
+		assert.NoError(snap.Write(path))
+		loaded, err := LoadSnapshot(path)
+		assert.NoError(err)
+		assert.Equal(snap, loaded)
+	}
+
+	assert.True(snap.TableExists("test_user"))
+	assert.False(snap.TableExists("test_address"))
+	key, _ := snap.CompositeKey("test_user")
+	assert.Equal("test_user", key[0].TableName)
+	assert.True(snap.EnumExists("user_yesno_type"))
+	volatile, err := isVolatileDefault(snap, "random() * 10")
+	assert.NoError(err)
+	assert.True(volatile)
+	volatile, err = isVolatileDefault(snap, "now()")
+	assert.NoError(err)
+	assert.False(volatile)
+}
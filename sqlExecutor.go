@@ -0,0 +1,169 @@
+package shifter
+
+import (
+	"context"
+	"database/sql"
+)
+
+//sqlExecutor is the executor of database/sql connection
+type sqlExecutor struct {
+	db *sql.DB
+}
+
+//sqlTx is the transaction of database/sql connection
+type sqlTx struct {
+	tx *sql.Tx
+}
+
+//sqlSession is the session of database/sql connection
+type sqlSession struct {
+	conn sqlConn
+}
+
+//sqlConn is the reserved connection of database/sql pool
+type sqlConn struct {
+	*sql.Conn
+}
+
+//sqlQuerier is the common methods of database/sql connection and transaction
+type sqlQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+//sqlResult is the result of sql executed by database/sql
+type sqlResult struct {
+	rows int
+}
+
+//sqlRows reads the rows of database/sql query result
+type sqlRows struct {
+	*sql.Rows
+	count int
+}
+
+//NewSQLExecutor will return executor of database/sql connection
+//i.e. connection opened using lib/pq or pgx stdlib driver.
+//Params are formatted in the query so no driver specific placeholder is used
+func NewSQLExecutor(db *sql.DB) Executor {
+	return sqlExecutor{db: db}
+}
+
+//Exec will execute the sql
+func (e sqlExecutor) Exec(query string, params ...interface{}) (Result, error) {
+	return sqlExec(e.db, query, params...)
+}
+
+//Query will execute the query and scan the rows in dest
+func (e sqlExecutor) Query(dest interface{}, query string, params ...interface{}) error {
+	return sqlQuery(e.db, dest, query, params...)
+}
+
+//Begin will begin the transaction
+func (e sqlExecutor) Begin() (Tx, error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return sqlTx{tx: tx}, nil
+}
+
+//Session will reserve a connection of database/sql pool
+//which is returned to the pool on close
+func (e sqlExecutor) Session() (Session, error) {
+	conn, err := e.db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return sqlSession{conn: sqlConn{Conn: conn}}, nil
+}
+
+//Exec will execute the sql in session
+func (s sqlSession) Exec(query string, params ...interface{}) (Result, error) {
+	return sqlExec(s.conn, query, params...)
+}
+
+//Query will execute the query in session and scan the rows in dest
+func (s sqlSession) Query(dest interface{}, query string, params ...interface{}) error {
+	return sqlQuery(s.conn, dest, query, params...)
+}
+
+//Close will return the connection of session to the pool
+func (s sqlSession) Close() error {
+	return s.conn.Close()
+}
+
+//Exec will execute the sql in transaction
+func (t sqlTx) Exec(query string, params ...interface{}) (Result, error) {
+	return sqlExec(t.tx, query, params...)
+}
+
+//Query will execute the query in transaction and scan the rows in dest
+func (t sqlTx) Query(dest interface{}, query string, params ...interface{}) error {
+	return sqlQuery(t.tx, dest, query, params...)
+}
+
+//Commit will commit the transaction
+func (t sqlTx) Commit() error {
+	return t.tx.Commit()
+}
+
+//Rollback will rollback the transaction
+func (t sqlTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+//Exec will execute the sql on reserved connection
+func (c sqlConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), query, args...)
+}
+
+//Query will execute the query on reserved connection
+func (c sqlConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query, args...)
+}
+
+//RowsAffected will return number of rows affected by the sql
+func (r sqlResult) RowsAffected() int {
+	return r.rows
+}
+
+//Values will return values of the current row
+func (r *sqlRows) Values() (values []interface{}, err error) {
+	values = make([]interface{}, r.count)
+	dest := make([]interface{}, r.count)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	err = r.Scan(dest...)
+	return
+}
+
+//sqlExec will execute the sql using database/sql
+func sqlExec(db sqlQuerier, query string, params ...interface{}) (res Result, err error) {
+	var (
+		result sql.Result
+		rows   int64
+	)
+	if result, err = db.Exec(formatQuery(query, params...)); err == nil {
+		//driver may not support rows affected of multiple statements
+		rows, _ = result.RowsAffected()
+		res = sqlResult{rows: int(rows)}
+	}
+	return
+}
+
+//sqlQuery will execute the query using database/sql and scan the rows in dest
+func sqlQuery(db sqlQuerier, dest interface{}, query string, params ...interface{}) (err error) {
+	var (
+		rows    *sql.Rows
+		columns []string
+	)
+	if rows, err = db.Query(formatQuery(query, params...)); err == nil {
+		defer rows.Close()
+		if columns, err = rows.Columns(); err == nil {
+			err = scanRows(dest, columns, &sqlRows{Rows: rows, count: len(columns)})
+		}
+	}
+	return
+}
@@ -0,0 +1,96 @@
+package shifter
+
+import (
+	m "github.com/mayur-tolexo/pg-shifter/model"
+)
+
+//runState is the bookkeeping of a single shifter run
+//it is reset at the start of every public operation
+type runState struct {
+	tableCreated map[string]struct{}
+	enumCreated  map[string]struct{}
+	dryRun       bool
+	changes      []Change
+	journalLog   []m.MigrationLog
+	executed     []Change
+	blocked      []Change
+	deferredFK   map[string][]fkRef
+	pendingFK    []fkRef
+	//schemas created in this run
+	schemas map[string]struct{}
+	//online steps executed after alter is committed
+	online []Change
+	//tables created in this run
+	newTables map[string]struct{}
+	//transaction holding the advisory lock
+	lockTx Tx
+	//executor of the run used after transaction is committed
+	conn Executor
+	//offline catalog used instead of database
+	catalog Catalog
+	//sql of the current transaction failed with lock timeout
+	lockTimeout bool
+	//journal table is created in this run
+	journalCreated bool
+}
+
+//txState is the run state changed by a transaction
+//which is restored when the transaction is retried
+type txState struct {
+	tableCreated map[string]struct{}
+	enumCreated  map[string]struct{}
+	schemas      map[string]struct{}
+	newTables    map[string]struct{}
+	pendingFK    []fkRef
+}
+
+//newRunState will return empty run state
+func newRunState() runState {
+	return runState{
+		tableCreated: make(map[string]struct{}),
+		enumCreated:  make(map[string]struct{}),
+		schemas:      make(map[string]struct{}),
+		newTables:    make(map[string]struct{}),
+	}
+}
+
+//getTxState will return copy of the run state changed by a transaction
+func (s *Shifter) getTxState() txState {
+	return txState{
+		tableCreated: copySet(s.run.tableCreated),
+		enumCreated:  copySet(s.run.enumCreated),
+		schemas:      copySet(s.run.schemas),
+		newTables:    copySet(s.run.newTables),
+		pendingFK:    append([]fkRef{}, s.run.pendingFK...),
+	}
+}
+
+//setTxState will restore the run state changed by a rolled back transaction
+func (s *Shifter) setTxState(state txState) {
+	s.run.tableCreated, s.run.enumCreated = state.tableCreated, state.enumCreated
+	s.run.schemas, s.run.newTables = state.schemas, state.newTables
+	s.run.pendingFK = state.pendingFK
+}
+
+//copySet will return copy of the set
+func copySet(set map[string]struct{}) (cp map[string]struct{}) {
+	cp = make(map[string]struct{}, len(set))
+	for k := range set {
+		cp[k] = struct{}{}
+	}
+	return
+}
+
+//lock will lock the shifter for a public operation and reset the run state
+//so that shifter can be used from multiple goroutines
+func (s *Shifter) lock() {
+	s.mu.Lock()
+	s.run = newRunState()
+}
+
+//unlock will release the advisory lock if acquired
+//and unlock the shifter after public operation
+func (s *Shifter) unlock() {
+	s.releaseAdvisoryLock()
+	s.mu.Unlock()
+}
@@ -0,0 +1,42 @@
+package shifter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunState(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	s.run.tableCreated["test_user"] = struct{}{}
+	s.run.enumCreated["user_yesno_type"] = struct{}{}
+	s.run.dryRun = true
+
+	s.lock()
+	assert.Empty(s.run.tableCreated)
+	assert.Empty(s.run.enumCreated)
+	assert.False(s.run.dryRun)
+	s.unlock()
+}
+
+func TestConcurrentShifter(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.SetTableModel(&db.TestUser{})
+			s.SetTableModel(&db.TestAddress{})
+			s.GetStructSchema("test_user")
+			s.DependencyOrder()
+			s.Verbose(false)
+		}()
+	}
+	wg.Wait()
+	assert.Equal([]string{"test_address", "test_user"}, s.TableNames())
+}
@@ -45,6 +45,13 @@ var rPGAlias = map[string]string{
 
 //GetStructSchema will return struct schema
 func (s *Shifter) GetStructSchema(tableName string) (sSchema map[string]model.ColSchema) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getStructSchema(tableName)
+}
+
+//getStructSchema will return struct schema
+func (s *Shifter) getStructSchema(tableName string) (sSchema map[string]model.ColSchema) {
 	tModel, isValid := s.table[tableName]
 	sSchema = make(map[string]model.ColSchema)
 	if isValid {
@@ -125,13 +132,13 @@ func (s *Shifter) setColConstraint(schema *model.ColSchema, tag string) {
 		cSet = true
 		schema.ConstraintType = primaryKey
 		//in case of primary key reference table is itself
-		schema.ForeignTableName = schema.TableName
+		schema.ForeignTableName = util.GetBareName(schema.TableName)
 		schema.ForeignColumnName = schema.ColumnName
 	} else if strings.Contains(tag, uniqueKeyTag) {
 		cSet = true
 		schema.ConstraintType = uniqueKey
 		//in case of unique key reference table is itself
-		schema.ForeignTableName = schema.TableName
+		schema.ForeignTableName = util.GetBareName(schema.TableName)
 	}
 	if strings.Contains(tag, referencesTag) {
 		cSet = true
@@ -144,6 +151,7 @@ func (s *Shifter) setColConstraint(schema *model.ColSchema, tag string) {
 		//setting reference table and on cascade flags
 		if len(referenceCheck) > 1 {
 			schema.ForeignTableName, schema.ForeignColumnName = getFkDetail(referenceCheck[1])
+			schema.ForeignTableName = getRefTableName(schema.TableName, schema.ForeignTableName)
 			schema.DeleteType = getConstraintFlagByKey(referenceCheck[1], deleteTag)
 			schema.UpdateType = getConstraintFlagByKey(referenceCheck[1], updateTag)
 		}
@@ -230,7 +238,7 @@ func getConstraintFlag(key string) (flag string) {
 		flag = "c"
 	case setNullTag:
 		flag = "n"
-	case setDefault:
+	case setdefaultTag:
 		flag = "d"
 	default:
 		flag = "a"
@@ -5,22 +5,22 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/go-pg/pg"
-	"github.com/go-pg/pg/orm"
 	"github.com/mayur-tolexo/flaw"
 	"github.com/mayur-tolexo/pg-shifter/model"
 	"github.com/mayur-tolexo/pg-shifter/util"
 )
 
 //Create Table in database
-func (s *Shifter) createTable(tx *pg.Tx, tableName string, withDependency bool) (err error) {
-	tableModel := s.table[tableName]
-	if _, alreadyCreated := tableCreated[tableModel]; alreadyCreated == false {
-		tableCreated[tableModel] = true
-		err = s.upsertAllEnum(tx, tableName)
+func (s *Shifter) createTable(tx Tx, tableName string, withDependency bool) (err error) {
+	if _, alreadyCreated := s.run.tableCreated[tableName]; alreadyCreated == false {
+		s.run.tableCreated[tableName] = struct{}{}
+		//creating table schema before enums as enums are created in it
+		if err = s.createSchema(tx, tableName); err == nil {
+			err = s.upsertAllEnum(tx, tableName)
+		}
 		if err == nil {
 			if withDependency {
-				err = s.createTableDependencies(tx, tableModel)
+				err = s.createTableDependencies(tx, tableName)
 			}
 			if err == nil {
 				err = s.execTableCreation(tx, tableName)
@@ -31,20 +31,18 @@ func (s *Shifter) createTable(tx *pg.Tx, tableName string, withDependency bool)
 }
 
 //Create all Tables if not exists whose Fk present in table Model
-func (s *Shifter) createTableDependencies(tx *pg.Tx, tableModel interface{}) (err error) {
-	fields := util.GetStructField(tableModel)
-	for _, curField := range fields {
-		refTable := util.RefTable(curField)
+func (s *Shifter) createTableDependencies(tx Tx, tableName string) (err error) {
+	for _, refTable := range s.getRefTables(tableName) {
 		if len(refTable) > 0 {
-			if refTableModel, isValid := s.table[refTable]; isValid == true {
-				if _, alreadyCreated := tableCreated[refTableModel]; alreadyCreated == false {
+			if _, isValid := s.table[refTable]; isValid == true {
+				if _, alreadyCreated := s.run.tableCreated[refTable]; alreadyCreated == false {
 
 					//creating ref table dep tables
-					tableCreated[refTableModel] = true
+					s.run.tableCreated[refTable] = struct{}{}
 					//create/update enum
 					if err = s.upsertAllEnum(tx, refTable); err == nil {
 						//creating dependent table
-						if err = s.createTableDependencies(tx, refTableModel); err == nil {
+						if err = s.createTableDependencies(tx, refTable); err == nil {
 							//executin table creatin sql
 							err = s.execTableCreation(tx, refTable)
 						}
@@ -59,42 +57,68 @@ func (s *Shifter) createTableDependencies(tx *pg.Tx, tableModel interface{}) (er
 	return
 }
 
+//getRefTables will return tables referenced by foreign keys
+//of table struct sql tag and ForeignKeys() method
+func (s *Shifter) getRefTables(tableName string) (refTables []string) {
+	for _, curField := range util.GetStructField(s.table[tableName]) {
+		refTables = append(refTables, util.RefTable(curField))
+	}
+	for _, key := range s.getCompositeKeyFromMethod(tableName) {
+		if key.ConstraintType == foreignKey {
+			refTables = append(refTables, key.ForeignTableName)
+		}
+	}
+	return
+}
+
 //execTableCreation will execute table creation
-func (s *Shifter) execTableCreation(tx *pg.Tx, tableName string) (err error) {
+func (s *Shifter) execTableCreation(tx Tx, tableName string) (err error) {
 	tableModel := s.table[tableName]
 
 	exists := false
-	if tableExists(tx, tableName) {
+	if s.catalog(tx).TableExists(tableName) {
 		exists = true
 	}
 
 	if exists == false {
-		if err = tx.CreateTable(tableModel,
-			&orm.CreateTableOptions{IfNotExists: true}); err == nil {
+		var sql string
+		s.run.newTables[tableName] = struct{}{}
+		if err = s.createSchema(tx, tableName); err == nil {
+			sql, err = getCreateTableSQL(tableModel)
+		}
+		if err == nil {
+			sql = s.removeDeferredFK(tableName, sql)
+			sql = s.getCreateCheckSQL(tableName, sql)
+			sql = s.getEnumCreateTableSQL(tableName, sql)
+			c := newChange(CreateTableChange, tableName, "", sql)
+			c.DownSQL = getDropTableSQL(tableName)
+			if err = s.exec(tx, c); err == nil {
+				_, err = s.addCompositeKey(tx, tableName, s.getCreateCompositeKey(tableName), true)
+			}
+		}
+		if err == nil {
 
 			if err = s.createHistory(tx, tableName); err == nil {
 				if sql := s.getPostCreateSQLFromMethod(tableName); sql != "" {
-					if _, err = tx.Exec(sql); err != nil {
-						err = getWrapError(tableName, "Post Table Create SQL", sql, err)
-					}
+					err = s.exec(tx, newChange(PostCreateChange, tableName, "", sql))
 				}
 			}
 
-			if err == nil {
+			if err == nil && s.run.dryRun == false {
 				fmt.Println("Table created: ", tableName)
 			}
 		} else {
 			err = flaw.CreateError(err)
 			fmt.Println("Table Error:", tableName, err.Error())
 		}
-	} else {
+	} else if s.run.dryRun == false {
 		fmt.Println("Table already exists: ", tableName)
 	}
 	return
 }
 
 //dropTable will drop table
-func (s *Shifter) dropTable(tx *pg.Tx, tableName string, cascade bool) (err error) {
+func (s *Shifter) dropTable(tx Tx, tableName string, cascade, skipPrompt bool) (err error) {
 	var (
 		log    sLog
 		fData  []byte
@@ -102,8 +126,10 @@ func (s *Shifter) dropTable(tx *pg.Tx, tableName string, cascade bool) (err erro
 	)
 	if log, fData, exists, err = s.generateTableStructSchema(tx, tableName, true); err == nil &&
 		exists {
-		if err = execTableDrop(tx, tableName, cascade); err == nil {
-			if err = s.dropHistory(tx, tableName, cascade); err == nil {
+		var isAlter bool
+		if isAlter, err = s.execTableDrop(tx, tableName, cascade, skipPrompt); err == nil {
+			if err = s.dropHistory(tx, tableName, cascade, skipPrompt); err == nil && isAlter &&
+				s.run.dryRun == false {
 				err = s.logTableChange(log, fData)
 			}
 		}
@@ -112,19 +138,26 @@ func (s *Shifter) dropTable(tx *pg.Tx, tableName string, cascade bool) (err erro
 }
 
 //execTableDrop will execute table drop
-func execTableDrop(tx *pg.Tx, tableName string, cascade bool) (err error) {
-	sql := fmt.Sprintf("DROP TABLE IF EXISTS %v", tableName)
+//drop is destructive so it is checked by the policy
+func (s *Shifter) execTableDrop(tx Tx, tableName string, cascade, skipPrompt bool) (
+	isAlter bool, err error) {
+	sql := getDropTableSQL(tableName)
 	if cascade {
 		sql += " CASCADE"
 	}
-	if _, err = tx.Exec(sql); err == nil {
+	c := newChange(DropTableChange, tableName, "", sql)
+	if isAlter, err = s.execByChoice(tx, c, skipPrompt); err == nil && isAlter && s.run.dryRun == false {
 		fmt.Println("Table Dropped if exists: ", tableName)
-	} else {
-		err = getWrapError(tableName, "drop table", sql, err)
 	}
 	return
 }
 
+//getDropTableSQL will return drop table sql
+func getDropTableSQL(tableName string) (sql string) {
+	sql = fmt.Sprintf("DROP TABLE IF EXISTS %v", tableName)
+	return
+}
+
 //getPostCreateSQLFromMethod will return post table creation sql need to executed
 //as defined in PostCreateSQL() method
 func (s *Shifter) getPostCreateSQLFromMethod(tName string) (sql string) {
@@ -142,49 +175,62 @@ func (s *Shifter) getPostCreateSQLFromMethod(tName string) (sql string) {
 }
 
 //getConstraint : Get Constraint of table from database
-func getConstraint(tx *pg.Tx, tableName string) (constraint []model.ColSchema, err error) {
+func getConstraint(tx Tx, tableName string) (constraint []model.ColSchema, err error) {
 	query := `SELECT tc.constraint_type,
     tc.constraint_name, tc.is_deferrable, tc.initially_deferred, 
-    kcu.column_name AS column_name, ccu.table_name AS foreign_table_name, 
+    kcu.column_name AS column_name, CASE WHEN ccu.table_schema = tc.table_schema
+    THEN ccu.table_name ELSE ccu.table_schema || '.' || ccu.table_name END AS foreign_table_name, 
     ccu.column_name AS foreign_column_name, pgc.confupdtype, pgc.confdeltype  
     FROM 
     information_schema.table_constraints AS tc 
     JOIN information_schema.key_column_usage AS kcu 
     ON tc.constraint_name = kcu.constraint_name 
+    AND tc.constraint_schema = kcu.constraint_schema 
     JOIN information_schema.constraint_column_usage AS ccu 
     ON ccu.constraint_name = tc.constraint_name 
+    AND ccu.constraint_schema = tc.constraint_schema 
     JOIN pg_constraint AS pgc ON pgc.conname = tc.constraint_name AND 
     conrelid=?::regclass::oid WHERE tc.constraint_type 
     IN('FOREIGN KEY','PRIMARY KEY','UNIQUE') AND tc.table_name = ?
+    AND tc.table_schema = COALESCE(NULLIF(?, ''), current_schema())
     AND array_length(pgc.conkey,1) = 1;`
-	if _, err = tx.Query(&constraint, query, tableName, tableName); err != nil {
+	schema, table := util.SplitTableName(tableName)
+	if err = tx.Query(&constraint, query, tableName, table, schema); err != nil {
 		err = getWrapError(tableName, "table constraint", query, err)
 	}
 	return
 }
 
 //getColumnSchema : Get Column Schema of given table
-func getColumnSchema(tx *pg.Tx, tableName string) (columnSchema []model.ColSchema, err error) {
+func getColumnSchema(tx Tx, tableName string) (columnSchema []model.ColSchema, err error) {
 	query := `SELECT col.column_name, col.column_default, col.data_type,
 	col.ordinal_position as position,
-	col.udt_name, col.is_nullable, col.character_maximum_length 
+	col.udt_name, col.is_nullable,
+	CASE WHEN col.data_type = 'numeric' AND col.numeric_precision IS NOT NULL
+	THEN col.numeric_precision || ',' || col.numeric_scale
+	ELSE col.character_maximum_length::text END AS character_maximum_length
 	, sq.sequence_name AS seq_name
 	, sq.data_type AS seq_data_type
 	FROM information_schema.columns col
 	left join information_schema.sequences sq
-	ON concat(sq.sequence_schema,'.',sq.sequence_name) = pg_get_serial_sequence(table_name, column_name)
-	WHERE col.table_name = ?;`
-	if _, err = tx.Query(&columnSchema, query, tableName); err != nil {
+	ON concat(sq.sequence_schema,'.',sq.sequence_name) = pg_get_serial_sequence(
+		quote_ident(col.table_schema) || '.' || quote_ident(col.table_name), col.column_name)
+	WHERE col.table_name = ?
+	AND col.table_schema = COALESCE(NULLIF(?, ''), current_schema());`
+	schema, table := util.SplitTableName(tableName)
+	if err = tx.Query(&columnSchema, query, table, schema); err != nil {
 		err = getWrapError(tableName, "column schema", query, err)
 	}
 	return
 }
 
 //tableExists : Check if table exists in database
-func tableExists(tx *pg.Tx, tableName string) (flag bool) {
+func tableExists(tx Tx, tableName string) (flag bool) {
 	var num int
-	sql := `SELECT 1 FROM pg_tables WHERE tablename = ?;`
-	if _, err := tx.Query(pg.Scan(&num), sql, tableName); err != nil {
+	sql := `SELECT 1 FROM pg_tables WHERE tablename = ?
+	AND schemaname = COALESCE(NULLIF(?, ''), current_schema());`
+	schema, table := util.SplitTableName(tableName)
+	if err := tx.Query(&num, sql, table, schema); err != nil {
 		fmt.Println("Table exists check error", err)
 	} else if num == 1 {
 		flag = true
@@ -5,25 +5,29 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/go-pg/pg"
 	"github.com/mayur-tolexo/pg-shifter/util"
 )
 
 //Create trigger
-func (s *Shifter) createTrigger(tx *pg.Tx, tableName string) (err error) {
+func (s *Shifter) createTrigger(tx Tx, tableName string) (err error) {
 	if s.isSkip(tableName) == false {
 		defer s.logMode(false)
-		trigger := s.GetTrigger(tableName)
+		trigger := s.getTrigger(tableName)
 		s.logMode(s.verbose)
-		if _, err = tx.Exec(trigger); err != nil {
-			err = getWrapError(tableName, "create trigger", trigger, err)
-		}
+		err = s.exec(tx, newChange(CreateTriggerChange, tableName, "", trigger))
 	}
 	return
 }
 
 //GetTrigger : Get triggers by table name
 func (s *Shifter) GetTrigger(tableName string) (trigger string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getTrigger(tableName)
+}
+
+//getTrigger will return triggers of table
+func (s *Shifter) getTrigger(tableName string) (trigger string) {
 	var (
 		// aInsertTrigger string
 		bUpdateTrigger string
@@ -87,13 +91,15 @@ func (s *Shifter) getAfterInsertTrigger(tableName, fields, values string) (
 	$$
 	LANGUAGE 'plpgsql';
 		`, afterInsertTable, historyTable, fields, values)
+	//trigger name can not be schema qualified
+	triggerName := util.GetBareName(afterInsertTable)
 	triggerQuery := fmt.Sprintf(`
 	DROP TRIGGER IF EXISTS %v ON %v;
 	CREATE TRIGGER %v
 	AFTER INSERT ON %v 
 	FOR EACH ROW
 	EXECUTE PROCEDURE %v();`+delimiter,
-		afterInsertTable, tableName, afterInsertTable, tableName, afterInsertTable)
+		triggerName, tableName, triggerName, tableName, afterInsertTable)
 	aInsertTrigger = fnQuery + triggerQuery + "\n"
 	return
 }
@@ -128,6 +134,10 @@ func (s *Shifter) getAfterUpdateTrigger(tableName, fields, values,
 	RETURNS trigger AS
 	$$
 		BEGIN
+			IF current_setting('%v', true) = 'on'
+			THEN
+				RETURN NEW;
+			END IF;
 			IF %v
 			THEN
         	INSERT INTO %v (
@@ -140,14 +150,15 @@ func (s *Shifter) getAfterUpdateTrigger(tableName, fields, values,
 		END;
 	$$
 	LANGUAGE 'plpgsql';
-		`, afterUpdateTable, updateCondition, historyTable, fields, values)
+		`, afterUpdateTable, skipTriggerSetting, updateCondition, historyTable, fields, values)
+	triggerName := util.GetBareName(afterUpdateTable)
 	triggerQuery := fmt.Sprintf(`
 	DROP TRIGGER IF EXISTS %v ON %v;
 	CREATE TRIGGER %v
 	AFTER UPDATE ON %v 
 	FOR EACH ROW
 	EXECUTE PROCEDURE %v();`+delimiter,
-		afterUpdateTable, tableName, afterUpdateTable, tableName, afterUpdateTable)
+		triggerName, tableName, triggerName, tableName, afterUpdateTable)
 	aUpdateTrigger = fnQuery + triggerQuery + "\n"
 	return
 }
@@ -164,19 +175,23 @@ func (s *Shifter) getBeforeUpdateTrigger(tableName string) (bUpdateTrigger strin
 	RETURNS trigger AS
 	$$
     	BEGIN
-        	NEW.updated_at = now();
+        	IF current_setting('%v', true) IS DISTINCT FROM 'on'
+        	THEN
+        		NEW.updated_at = now();
+        	END IF;
         	RETURN NEW;
     	END;
 	$$
 	LANGUAGE 'plpgsql';
-		`, beforeUpdateTable)
+		`, beforeUpdateTable, skipTriggerSetting)
+	triggerName := util.GetBareName(beforeUpdateTable)
 	triggerQuery := fmt.Sprintf(`
 	DROP TRIGGER IF EXISTS %v ON %v;
 	CREATE TRIGGER %v
 	BEFORE UPDATE ON %v 
 	FOR EACH ROW
 	EXECUTE PROCEDURE %v();`+delimiter,
-		beforeUpdateTable, tableName, beforeUpdateTable, tableName, beforeUpdateTable)
+		triggerName, tableName, triggerName, tableName, beforeUpdateTable)
 	bUpdateTrigger = fnQuery + triggerQuery + "\n"
 	return
 }
@@ -216,13 +231,14 @@ func (s *Shifter) getAfterDeleteTrigger(tableName, fields, values string) (aDele
 	$$
 	LANGUAGE 'plpgsql';
 		`, afterDeleteTable, historyTable, fields, values)
+	triggerName := util.GetBareName(afterDeleteTable)
 	triggerQuery := fmt.Sprintf(`
 	DROP TRIGGER IF EXISTS %v ON %v;
 	CREATE TRIGGER %v
 	AFTER DELETE ON %v 
 	FOR EACH ROW
 	EXECUTE PROCEDURE %v();`+delimiter,
-		afterDeleteTable, tableName, afterDeleteTable, tableName, afterDeleteTable)
+		triggerName, tableName, triggerName, tableName, afterDeleteTable)
 	aDeleteTrigger = fnQuery + triggerQuery + "\n"
 	return
 }
@@ -231,9 +247,8 @@ func (s *Shifter) getAfterDeleteTrigger(tableName, fields, values string) (aDele
 func (s *Shifter) getHistoryFields(dbModel interface{}, dataTag, action string) (
 	fields string, values string, updateCondition string, updatedAt bool, err error) {
 
-	fieldMap := util.GetStructField(dbModel)
 	fCount, uCount := 0, 0
-	for _, inputField := range fieldMap {
+	for _, inputField := range util.GetSortedStructField(dbModel) {
 		if tagValue, exists := inputField.Tag.Lookup("sql"); exists == true {
 
 			curField := strings.Split(tagValue, ",")
@@ -269,3 +284,33 @@ func getNewline(count int) (sep string) {
 	}
 	return
 }
+
+//afterUpdateTriggerExists will check after update trigger of history table exists
+func (s *Shifter) afterUpdateTriggerExists(tx Tx, tableName string) (
+	exists bool, err error) {
+
+	var trigger []string
+	afterUpdate := util.GetBareName(util.GetAfterUpdateTriggerName(tableName))
+	if trigger, err = s.catalog(tx).Trigger(tableName); err == nil {
+		for _, name := range trigger {
+			if name == afterUpdate {
+				exists = true
+				break
+			}
+		}
+	}
+	return
+}
+
+//getDBTrigger : Get trigger names of table from database in sorted order
+func getDBTrigger(tx Tx, tableName string) (names []string, err error) {
+	query := `SELECT DISTINCT trigger_name FROM information_schema.triggers
+	WHERE event_object_table = ?
+	AND event_object_schema = COALESCE(NULLIF(?, ''), current_schema())
+	ORDER BY trigger_name;`
+	schema, table := util.SplitTableName(tableName)
+	if err = tx.Query(&names, query, table, schema); err != nil {
+		err = getWrapError(tableName, "trigger", query, err)
+	}
+	return
+}
@@ -3,9 +3,9 @@ package shifter
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/go-pg/pg"
 	"github.com/mayur-tolexo/pg-shifter/model"
 	"github.com/mayur-tolexo/pg-shifter/util"
 )
@@ -21,9 +21,10 @@ func (s *Shifter) getUKFromMethod(tName string) (uk map[string]string) {
 		if len(out) > 0 && out[0].Kind() == reflect.Slice {
 			val := out[0].Interface().([]string)
 			for _, ukFields := range val {
+				ukFields = getTrimmedColumns(ukFields)
 				fName := strings.Replace(ukFields, ",", "_", -1)
-				ukName := fmt.Sprintf("%v_%v_%v", tName, fName, uniqueKeySuffix)
-				ukName = util.GetStrByLen(ukName, 64)
+				ukName := fmt.Sprintf("%v_%v_%v", util.GetBareName(tName), fName, uniqueKeySuffix)
+				ukName = util.GetUniqueStrByLen(ukName, 64)
 				uk[ukName] = ukFields
 			}
 		}
@@ -32,12 +33,12 @@ func (s *Shifter) getUKFromMethod(tName string) (uk map[string]string) {
 }
 
 //Check unique key constraint to alter
-func (s *Shifter) checkUniqueKeyToAlter(tx *pg.Tx, tName string,
+func (s *Shifter) checkUniqueKeyToAlter(tx Tx, tName string,
 	tUK []model.UKSchema, sUK map[string]string) (isAlter bool, err error) {
 
-	if isAlter, err = dropCompositeUK(tx, tName, tUK, sUK, true); err == nil {
+	if isAlter, err = s.dropCompositeUK(tx, tName, tUK, sUK, true); err == nil {
 		var curAlter bool
-		curAlter, err = addCompositeUK(tx, tName, sUK, true)
+		curAlter, err = s.addCompositeUK(tx, tName, sUK, true)
 		isAlter = isAlter || curAlter
 	}
 
@@ -45,39 +46,55 @@ func (s *Shifter) checkUniqueKeyToAlter(tx *pg.Tx, tName string,
 }
 
 //addCompositeUK will add composite unique key which is not in table
-func addCompositeUK(tx *pg.Tx, tName string, sUK map[string]string, skipPrompt bool) (
+func (s *Shifter) addCompositeUK(tx Tx, tName string, sUK map[string]string, skipPrompt bool) (
 	isAlter bool, err error) {
 
-	if len(sUK) > 0 {
-		sql := ""
-		for ukName, ukFields := range sUK {
+	if s.isConcurrent(tName) {
+		isAlter, err = s.addCompositeUKConcurrently(tx, tName, sUK, skipPrompt)
+	} else if len(sUK) > 0 {
+		sql, downSQL := "", ""
+		//sorted so that sql is same on every run
+		for _, ukName := range getSortedUKName(sUK) {
 			//only for more than one fields
-			if isCompositeUk(ukFields) {
+			if ukFields := sUK[ukName]; isCompositeUk(ukFields) {
 				sql += getUniqueKeyQuery(tName, ukName, ukFields)
+				downSQL += getDropConstraintSQL(tName, ukName)
 			}
 		}
 		if sql != "" {
-			if isAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-				err = getWrapError(tName, "add composite unique key", sql, err)
-			}
+			c := newChange(AddUniqueKeyChange, tName, "", sql)
+			c.DownSQL = downSQL
+			isAlter, err = s.execByChoice(tx, c, skipPrompt)
 		}
 	}
 	return
 }
 
 //dropCompositeUK will drop composite unique key if not exists in struct
-func dropCompositeUK(tx *pg.Tx, tName string, tUK []model.UKSchema,
+//or if its columns are changed in struct
+func (s *Shifter) dropCompositeUK(tx Tx, tName string, tUK []model.UKSchema,
 	sUK map[string]string, skipPrompt bool) (isAlter bool, err error) {
 
 	for _, curTableUK := range tUK {
 		var curAlter bool
-		if _, exists := sUK[curTableUK.ConstraintName]; exists {
-			//TODO: check unique key diff
+		tFields := getTrimmedColumns(curTableUK.Columns)
+		sFields, nameExists := sUK[curTableUK.ConstraintName]
+		ukName, fieldsExists := getUKNameByFields(sUK, tFields)
+
+		if nameExists && sFields == tFields {
+			//unique key is not modified
 			delete(sUK, curTableUK.ConstraintName)
+		} else if nameExists == false && fieldsExists && isUKNameUsed(tUK, ukName) == false {
+			//same unique key exists in table with different name
+			//i.e. created with previously truncated name
+			delete(sUK, ukName)
 		} else {
+			//unique key is removed or its columns are modified in struct
+			//so dropping it and modified unique key will be created again
 			sql := getDropConstraintSQL(tName, curTableUK.ConstraintName)
-			if curAlter, err = execByChoice(tx, sql, skipPrompt); err != nil {
-				err = getWrapError(tName, "drop composite unique key", sql, err)
+			c := newChange(DropUniqueKeyChange, tName, curTableUK.Columns, sql)
+			c.DownSQL = getUniqueKeyQuery(tName, curTableUK.ConstraintName, curTableUK.Columns)
+			if curAlter, err = s.execByChoice(tx, c, skipPrompt); err != nil {
 				break
 			}
 		}
@@ -86,6 +103,28 @@ func dropCompositeUK(tx *pg.Tx, tName string, tUK []model.UKSchema,
 	return
 }
 
+//getUKNameByFields will return struct unique key name having given fields
+func getUKNameByFields(sUK map[string]string, fields string) (ukName string, exists bool) {
+	for name, sFields := range sUK {
+		if sFields == fields {
+			ukName, exists = name, true
+			break
+		}
+	}
+	return
+}
+
+//isUKNameUsed will check given unique key name exists in table unique keys
+func isUKNameUsed(tUK []model.UKSchema, ukName string) (used bool) {
+	for _, curTableUK := range tUK {
+		if curTableUK.ConstraintName == ukName {
+			used = true
+			break
+		}
+	}
+	return
+}
+
 //isCompositeUk will check unique is composite or not
 func isCompositeUk(fields string) (isComposite bool) {
 	if strings.Contains(fields, ",") {
@@ -102,21 +141,33 @@ func getUniqueKeyQuery(tableName string, constraintName string,
 }
 
 //getDBCompositeUniqueKey : Get composite unique key name and columns from database
-func getDBCompositeUniqueKey(tx *pg.Tx, tableName string) (ukSchema []model.UKSchema, err error) {
+func getDBCompositeUniqueKey(tx Tx, tableName string) (ukSchema []model.UKSchema, err error) {
 	query := `
 	with comp as (
 		select  c.column_name, pgc.conname
 		, array_position(pgc.conkey::int[],c.ordinal_position::int) as position
 		from pg_constraint as pgc join
-		information_schema.table_constraints tc on pgc.conname = tc.constraint_name,
+		information_schema.table_constraints tc on pgc.conname = tc.constraint_name
+		and tc.constraint_schema = pgc.connamespace::regnamespace::text,
 		unnest(pgc.conkey::int[]) as colNo join information_schema.columns as c
 		on c.ordinal_position = colNo and c.table_name = ?
+		and c.table_schema = COALESCE(NULLIF(?, ''), current_schema())
 		where array_length(pgc.conkey,1)>1 and pgc.contype='u'
-		and pgc.conrelid=c.table_name::regclass::oid
+		and pgc.conrelid = ?::regclass::oid
 		order by position
 	)
-	select string_agg(column_name,',') as col, conname
+	select string_agg(column_name,',' order by position) as col, conname
 	from comp group by conname;`
-	_, err = tx.Query(&ukSchema, query, tableName)
+	schema, table := util.SplitTableName(tableName)
+	err = tx.Query(&ukSchema, query, table, schema, tableName)
+	return
+}
+
+//getSortedUKName will return unique key names in sorted order
+func getSortedUKName(uk map[string]string) (names []string) {
+	for name := range uk {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 	return
 }
@@ -0,0 +1,46 @@
+package shifter
+
+import (
+	"testing"
+
+	"github.com/mayur-tolexo/pg-shifter/model"
+	"github.com/mayur-tolexo/pg-shifter/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropCompositeUK(t *testing.T) {
+	assert := assert.New(t)
+	s := NewShifter()
+	s.run.dryRun = true
+
+	tUK := []model.UKSchema{
+		{ConstraintName: "test_user_username_status_key", Columns: "username,status"},
+		{ConstraintName: "test_user_name_email_key", Columns: "name,email"},
+		{ConstraintName: "test_user_old_name_key", Columns: "email,name"},
+	}
+	sUK := map[string]string{
+		"test_user_username_status_key": "status,username",
+		"test_user_name_email_key":      "name,email",
+		"test_user_email_name_key":      "email,name",
+	}
+	isAlter, err := s.dropCompositeUK(nil, "test_user", tUK, sUK, true)
+	assert.NoError(err)
+	assert.True(isAlter)
+
+	//column order modified so dropped and will be created again
+	if assert.Len(s.run.changes, 1) {
+		assert.Equal(DropUniqueKeyChange, s.run.changes[0].Kind)
+		assert.Contains(s.run.changes[0].SQL, "test_user_username_status_key")
+	}
+	assert.Equal(map[string]string{"test_user_username_status_key": "status,username"}, sUK)
+}
+
+func TestGetUniqueStrByLen(t *testing.T) {
+	assert := assert.New(t)
+	prefix := "test_user_very_long_column_name_one_very_long_column_name_two_"
+	a := util.GetUniqueStrByLen(prefix+"a_key", 64)
+	b := util.GetUniqueStrByLen(prefix+"b_key", 64)
+	assert.NotEqual(a, b)
+	assert.True(len(a) < 64)
+	assert.Equal("short_key", util.GetUniqueStrByLen("short_key", 64))
+}
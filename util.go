@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-
-	"github.com/go-pg/pg"
 )
 
 //getStructTableName will return table name from table struct
@@ -30,6 +28,13 @@ func (s *Shifter) getStructTableName(table interface{}) (
 
 //SetTableModel will set table struct pointer to shifter
 func (s *Shifter) SetTableModel(table interface{}) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.setTableModel(table)
+}
+
+//setTableModel will set table struct pointer to shifter
+func (s *Shifter) setTableModel(table interface{}) (err error) {
 	var tableName string
 	if tableName, err = s.getStructTableName(table); err == nil {
 		s.table[tableName] = table
@@ -40,16 +45,28 @@ func (s *Shifter) SetTableModel(table interface{}) (err error) {
 // SetTableModels will set multiple table struct pointer to shifter.
 // You can set all the table struct pointers and then perform operation by table name only
 func (s *Shifter) SetTableModels(tables []interface{}) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, table := range tables {
-		if err = s.SetTableModel(table); err != nil {
+		if err = s.setTableModel(table); err != nil {
 			break
 		}
 	}
 	return
 }
 
+//TableNames will return table names set in shifter in sorted order
+func (s *Shifter) TableNames() (tables []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tables, _ = s.getTableNames(nil)
+	return
+}
+
 //SetEnum will set global enum list
 func (s *Shifter) SetEnum(enum map[string][]string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.enumList = enum
 	return
 }
@@ -89,7 +106,7 @@ func (s *Shifter) getTableName(model interface{}) (
 	if reflect.TypeOf(model).Kind() == reflect.String {
 		tableName = model.(string)
 	} else {
-		if err = s.SetTableModel(model); err == nil {
+		if err = s.setTableModel(model); err == nil {
 			tableName, err = s.getStructTableName(model)
 		}
 	}
@@ -97,6 +114,11 @@ func (s *Shifter) getTableName(model interface{}) (
 	return
 }
 
+//getTrimmedColumns will return comma separated columns in lower case without space
+func getTrimmedColumns(columns string) string {
+	return strings.ToLower(strings.Replace(columns, " ", "", -1))
+}
+
 //getSP will return skip prompt value
 func getSP(val []bool) (skipPrompt bool) {
 	if len(val) > 0 {
@@ -106,10 +128,35 @@ func getSP(val []bool) (skipPrompt bool) {
 }
 
 //commitIfNil will commit transation if error is nil
-func commitIfNil(tx *pg.Tx, err error) {
+//and write the executed sql in journal if enabled
+//online steps queued in transaction are executed after commit
+//executed changes are recorded as applied only if transaction is committed
+//if any change is blocked by policy then transaction is rolled back with policy error
+//journal write error is returned if transaction and online steps have no error
+func (s *Shifter) commitIfNil(tx Tx, err error) error {
+	committed := false
+	if err == nil && len(s.run.blocked) > 0 {
+		err = &PolicyError{Blocked: s.run.blocked}
+	}
 	if err == nil {
-		tx.Commit()
+		if err = tx.Commit(); err == nil {
+			committed = true
+		}
 	} else {
 		tx.Rollback()
 	}
+	if committed {
+		s.applied = append(s.applied, s.run.executed...)
+	}
+	s.run.executed, s.run.blocked = nil, nil
+	jErr := s.writeJournal(s.run.conn, committed)
+	if committed {
+		//online steps are executed after alter is committed
+		err = s.execOnline(s.run.conn)
+	}
+	if err == nil {
+		err = jErr
+	}
+	s.run.online = nil
+	return err
 }
@@ -2,8 +2,10 @@ package util
 
 import (
 	"fmt"
+	"hash/fnv"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/go-pg/pg"
@@ -50,6 +52,18 @@ func GetStructField(model interface{}) (fields map[reflect.Value]reflect.StructF
 	return
 }
 
+//GetSortedStructField will return struct fields sorted by sql tag
+//so that sql generated from the fields is same on every run
+func GetSortedStructField(model interface{}) (fields []reflect.StructField) {
+	for _, field := range GetStructField(model) {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Tag.Get("sql") < fields[j].Tag.Get("sql")
+	})
+	return
+}
+
 func mergeMap(a, b map[reflect.Value]reflect.StructField) {
 	for k, v := range b {
 		a[k] = v
@@ -113,6 +127,23 @@ func SkipTag(object interface{}) (flag bool) {
 	return
 }
 
+//SplitTableName will return schema and table name from schema qualified table name
+//i.e. billing.invoice will return billing and invoice. Schema is empty if not qualified
+func SplitTableName(tableName string) (schema, table string) {
+	table = tableName
+	if idx := strings.LastIndex(tableName, "."); idx >= 0 {
+		schema, table = tableName[:idx], tableName[idx+1:]
+	}
+	return
+}
+
+//GetBareName will return table name without schema
+//this is used to create constraint, index and trigger names
+func GetBareName(tableName string) (table string) {
+	_, table = SplitTableName(tableName)
+	return
+}
+
 //GetHistoryTableName will reutrn history table name
 func GetHistoryTableName(tableName string) string {
 	return tableName + historyTag
@@ -144,11 +175,13 @@ func IsAfterUpdateTriggerExists(tx *pg.Tx, tName string) (exists bool, err error
 	sql := `
 	SELECT count(*) 
 	FROM information_schema.triggers 
-	WHERE event_object_table = ? 
+	WHERE event_object_schema = COALESCE(NULLIF(?, ''), current_schema())
+	AND event_object_table = ? 
 	AND trigger_name = ?
 	AND action_timing = 'AFTER'`
-	afterUpdate := GetAfterUpdateTriggerName(tName)
-	if _, err = tx.Query(&count, sql, tName, afterUpdate); err == nil && count > 0 {
+	schema, table := SplitTableName(tName)
+	afterUpdate := GetBareName(GetAfterUpdateTriggerName(tName))
+	if _, err = tx.Query(&count, sql, schema, table, afterUpdate); err == nil && count > 0 {
 		exists = true
 	}
 	return
@@ -161,3 +194,16 @@ func GetStrByLen(str string, n int) string {
 	}
 	return str
 }
+
+//GetUniqueStrByLen will return string shorter than given length.
+//If string is truncated then hash of the complete string is added as suffix
+//so that two different strings having same prefix won't collide
+func GetUniqueStrByLen(str string, n int) string {
+	if len(str) >= n {
+		h := fnv.New32a()
+		h.Write([]byte(str))
+		hash := fmt.Sprintf("%08x", h.Sum32())
+		str = str[:n-len(hash)-2] + "_" + hash
+	}
+	return str
+}